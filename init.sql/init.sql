@@ -4,30 +4,380 @@ CREATE TABLE IF NOT EXISTS users (
     login VARCHAR(255) UNIQUE NOT NULL,
     pass VARCHAR(255) NOT NULL,
     email VARCHAR(255) DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
     create_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Создание таблицы проектов
+CREATE TABLE IF NOT EXISTS projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#4a90d9',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id);
+
 -- Создание таблицы задач
 CREATE TABLE IF NOT EXISTS tasks (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 	deleted BOOLEAN DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ,
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     title VARCHAR(255) NOT NULL,
     description TEXT,
     status VARCHAR(20) DEFAULT 'active' CHECK (status IN ('active', 'completed')),
     priority VARCHAR(20) DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high')),
-    due_date DATE,
+    due_date TIMESTAMPTZ,
+    parent_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    state_id UUID,
+    recurrence JSONB,
+    series_id UUID,
+    occurrence INTEGER NOT NULL DEFAULT 1,
+    version INTEGER NOT NULL DEFAULT 1,
+    position DOUBLE PRECISION NOT NULL DEFAULT 0,
+    search_vector TSVECTOR,
     notified BOOLEAN DEFAULT FALSE,
     notification_sent_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Обновление существующей БД: CREATE TABLE IF NOT EXISTS не меняет уже созданные таблицы,
+-- поэтому колонки, появившиеся после первой версии схемы, добавляются отдельно
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES tasks(id) ON DELETE CASCADE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS state_id UUID;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS series_id UUID;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS occurrence INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+-- Задачи, удаленные до появления deleted_at, считаются удаленными в момент последнего изменения
+UPDATE tasks
+SET deleted_at = COALESCE(updated_at, created_at, now())
+WHERE deleted = true
+    AND deleted_at IS NULL;
+
+-- Срок раньше хранился датой: переводим в конец дня (часовой пояс всех пользователей тогда был UTC)
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_schema = current_schema()
+            AND table_name = 'tasks'
+            AND column_name = 'due_date'
+            AND data_type = 'date'
+    ) THEN
+        ALTER TABLE tasks
+            ALTER COLUMN due_date TYPE TIMESTAMPTZ
+            USING (due_date + interval '1 day' - interval '1 second') AT TIME ZONE 'UTC';
+    END IF;
+END
+$$;
+
 -- Создание индексов для задач
 CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks(user_id);
 CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
 CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
 CREATE INDEX IF NOT EXISTS idx_tasks_notification ON tasks(due_date, notified, deleted) WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id) WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id) WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_tasks_series ON tasks(series_id, occurrence) WHERE series_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_trash ON tasks(deleted_at) WHERE deleted = true;
+DROP INDEX IF EXISTS idx_tasks_board;
+CREATE INDEX IF NOT EXISTS idx_tasks_user_created ON tasks(user_id, created_at DESC, id DESC) WHERE deleted = false;
+
+-- Создание таблицы тегов
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags(tag_id);
+
+-- Создание таблицы участников задач
+CREATE TABLE IF NOT EXISTS task_members (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'editor')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_members_user_id ON task_members(user_id);
+
+-- Создание таблицы напоминаний
+CREATE TABLE IF NOT EXISTS reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    offset_minutes INTEGER CHECK (offset_minutes >= 0),
+    remind_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((offset_minutes IS NULL) <> (remind_at IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_task_id ON reminders(task_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(task_id) WHERE sent_at IS NULL;
+
+-- Задачи со сроком, созданные до появления напоминаний, получают напоминание за сутки
+INSERT INTO reminders (task_id, offset_minutes)
+SELECT t.id, 1440
+FROM tasks t
+WHERE t.due_date IS NOT NULL
+    AND t.deleted = false
+    AND NOT EXISTS (SELECT 1 FROM reminders r WHERE r.task_id = t.id);
+
+-- Создание таблицы комментариев к задачам
+CREATE TABLE IF NOT EXISTS task_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_created ON task_comments(task_id, created_at, id);
+
+-- Создание таблицы истории изменений задач
+CREATE TABLE IF NOT EXISTS task_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL,
+    field VARCHAR(50),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_created ON task_events(task_id, created_at DESC, id DESC);
+
+-- Создание таблицы ключей идемпотентности создания задач
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    task_id UUID REFERENCES tasks(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, key)
+);
+
+-- Полнотекстовый поиск по задачам. Конфигурация russian стеммит кириллицу
+-- русским словарем, а латиницу английским, поэтому покрывает оба языка.
+-- Вес A - название, B - описание, C - комментарии.
+CREATE OR REPLACE FUNCTION task_search_vector(p_task_id UUID, p_title TEXT, p_description TEXT) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('russian', coalesce(p_title, '')), 'A')
+        || setweight(to_tsvector('russian', coalesce(p_description, '')), 'B')
+        || setweight(to_tsvector('russian', coalesce((
+            SELECT string_agg(body, ' ') FROM task_comments WHERE task_id = p_task_id
+        ), '')), 'C')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION tasks_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := task_search_vector(NEW.id, NEW.title, NEW.description);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_search_vector ON tasks;
+CREATE TRIGGER tasks_search_vector
+    BEFORE INSERT OR UPDATE OF title, description ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_search_vector_update();
+
+CREATE OR REPLACE FUNCTION task_comments_search_vector_update() RETURNS TRIGGER AS $$
+DECLARE
+    v_task_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_task_id := OLD.task_id;
+    ELSE
+        v_task_id := NEW.task_id;
+    END IF;
+
+    UPDATE tasks
+    SET search_vector = task_search_vector(id, title, description)
+    WHERE id = v_task_id;
+
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_comments_search_vector ON task_comments;
+CREATE TRIGGER task_comments_search_vector
+    AFTER INSERT OR UPDATE OF body OR DELETE ON task_comments
+    FOR EACH ROW EXECUTE FUNCTION task_comments_search_vector_update();
+
+UPDATE tasks SET search_vector = task_search_vector(id, title, description) WHERE search_vector IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_search ON tasks USING GIN(search_vector) WHERE deleted = false;
+
+-- Создание таблицы позиций задач на доске: порядок у каждого пользователя свой
+CREATE TABLE IF NOT EXISTS task_positions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    position DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (user_id, task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_positions_task_id ON task_positions(task_id);
+
+-- Создание таблицы зависимостей: task_id заблокирована, пока не завершена blocker_id
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocker_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, blocker_id),
+    CHECK (task_id <> blocker_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocker ON task_dependencies(blocker_id);
+
+-- Создание таблицы состояний процесса
+CREATE TABLE IF NOT EXISTS workflow_states (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('active', 'completed')),
+    is_initial BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(user_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflow_states_initial ON workflow_states(user_id) WHERE is_initial;
+
+-- Создание таблицы разрешенных переходов
+CREATE TABLE IF NOT EXISTS workflow_transitions (
+    from_state_id UUID NOT NULL REFERENCES workflow_states(id) ON DELETE CASCADE,
+    to_state_id UUID NOT NULL REFERENCES workflow_states(id) ON DELETE CASCADE,
+    PRIMARY KEY (from_state_id, to_state_id)
+);
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_state_id_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_state_id_fkey FOREIGN KEY (state_id) REFERENCES workflow_states(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_state_id ON tasks(state_id) WHERE state_id IS NOT NULL;
+
+-- Создание таблицы сохраненных представлений
+CREATE TABLE IF NOT EXISTS saved_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, name)
+);
+
+-- Создание таблицы сессий. Сессия - это вход пользователя с одного устройства
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    last_seen_at TIMESTAMPTZ DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id) WHERE revoked_at IS NULL;
+
+-- Создание таблицы refresh токенов. Токены одной цепочки ротаций имеют общий family_id - ID сессии
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id);
+
+-- Создание таблицы двухфакторной аутентификации. enabled_at заполняется после подтверждения первым кодом,
+-- last_used_step - шаг последнего принятого кода TOTP (защита от повторного использования)
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- Создание таблицы одноразовых кодов восстановления (хранятся только хеши)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    UNIQUE (user_id, code_hash)
+);
+
+-- Создание таблицы токенов второго шага входа
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user ON login_challenges(user_id);
+
+-- Создание таблицы неудачных попыток входа: scope 'login' - по логину, 'ip' - по адресу клиента.
+-- blocked_until - до какого момента попытки отклоняются (задержка или блокировка)
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    blocked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure ON login_throttles(last_failure_at);
+
+-- Создание журнала блокировок входа
+CREATE TABLE IF NOT EXISTS login_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event VARCHAR(50) NOT NULL,
+    scope VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    failures INTEGER NOT NULL,
+    locked_until TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_audit_log_created ON login_audit_log(created_at);
 
 -- Вставка тестовых данных (опционально)
 INSERT INTO users (login, pass) VALUES 
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -15,11 +16,23 @@ type Config struct {
 	DBName                    string
 	DBSSLMode                 string
 	ServerPort                string
-	JWTSecret                 string
+	JWTKeysDir                string
+	JWTSigningKeyID           string
+	AccessTokenTTL            string
+	RefreshTokenTTL           string
+	LoginChallengeTTL         string
+	LoginMaxFailures          int
+	LoginIPMaxFailures        int
+	LoginBackoffBase          string
+	LoginBackoffMax           string
+	LoginLockoutDuration      string
+	LoginFailureWindow        string
 	NotificationServiceURL    string
 	NotificationCheckInterval string
 	KafkaBrokers              string
 	KafkaNotificationTopic    string
+	TrashRetention            string
+	TrashPurgeInterval        string
 }
 
 func Load() *Config {
@@ -33,11 +46,23 @@ func Load() *Config {
 		DBName:                    getEnv("DB_NAME", "taskManager"),
 		DBSSLMode:                 getEnv("DB_SSL_MODE", "disable"),
 		ServerPort:                getEnv("SERVER_PORT", "8080"),
-		JWTSecret:                 getEnv("JWT_SECRET", "your-default-secret-key"),
+		JWTKeysDir:                getEnv("JWT_KEYS_DIR", "./keys"),
+		JWTSigningKeyID:           getEnv("JWT_SIGNING_KEY_ID", ""),
+		AccessTokenTTL:            getEnv("ACCESS_TOKEN_TTL", "15m"),
+		RefreshTokenTTL:           getEnv("REFRESH_TOKEN_TTL", "720h"),
+		LoginChallengeTTL:         getEnv("LOGIN_CHALLENGE_TTL", "5m"),
+		LoginMaxFailures:          getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:        getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginBackoffBase:          getEnv("LOGIN_BACKOFF_BASE", "1s"),
+		LoginBackoffMax:           getEnv("LOGIN_BACKOFF_MAX", "1m"),
+		LoginLockoutDuration:      getEnv("LOGIN_LOCKOUT_DURATION", "15m"),
+		LoginFailureWindow:        getEnv("LOGIN_FAILURE_WINDOW", "15m"),
 		NotificationServiceURL:    getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service-app:8081"),
 		NotificationCheckInterval: getEnv("NOTIFICATION_CHECK_INTERVAL", "1m"),
 		KafkaBrokers:              getEnv("KAFKA_BROKERS", "kafka:9092"),
 		KafkaNotificationTopic:    getEnv("KAFKA_NOTIFICATION_TOPIC", "task-notifications"),
+		TrashRetention:            getEnv("TRASH_RETENTION", "720h"),
+		TrashPurgeInterval:        getEnv("TRASH_PURGE_INTERVAL", "1h"),
 	}
 }
 
@@ -58,14 +83,14 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-/*func getEnvAsInt(key string, defaultValue int) int {
+func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
 	}
 	return defaultValue
-}*/
+}
 
 func loadEnvFile(filename string) {
 	file, err := os.Open(filename)
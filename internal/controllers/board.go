@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrBoardNeighborNotFound = errors.New("соседняя задача не найдена в колонке")
+	ErrBoardNeighborsOrder   = errors.New("соседние задачи расположены в обратном порядке")
+	ErrBoardColumnNotFound   = errors.New("на доске нет такой колонки")
+)
+
+// boardColumn колонка доски, в которой текущий пользователь ($1) видит задачу t.
+// С процессом это состояние пользователя: одноименное состоянию задачи, иначе состояние
+// по умолчанию для статуса задачи (задачи чужого процесса). Без процесса - статус задачи.
+const boardColumn = `COALESCE(
+		(
+			SELECT vs.name
+			FROM workflow_states vs
+			INNER JOIN workflow_states ts ON ts.name = vs.name
+			WHERE vs.user_id = $1
+				AND vs.category = t.status
+				AND ts.id = t.state_id
+		),
+		(
+			SELECT vs.name
+			FROM workflow_states vs
+			WHERE vs.user_id = $1
+				AND vs.category = t.status
+			ORDER BY vs.is_initial DESC, vs.position
+			LIMIT 1
+		),
+		t.status
+	)`
+
+// newTaskPosition позиция новой задачи в конце доски владельца ($1) с учетом его собственного порядка
+const newTaskPosition = `COALESCE((
+			SELECT max(` + taskPosition + `)
+			FROM tasks t ` + taskAccessJoin + taskPositionJoin + `
+			WHERE t.deleted = false
+				AND t.status = 'active'
+				AND ` + taskReadable + `
+		), 0) + 1`
+
+// GetBoardDataBase возвращает задачи, сгруппированные по колонкам доски, в порядке позиций.
+// Колонки - состояния процесса пользователя или статусы, если процесса нет.
+// Учитываются условия фильтра, сортировка и курсор не используются.
+func GetBoardDataBase(db *sql.DB, UserID *string, filter *models.TaskFilter) (columns []models.BoardColumn, err error) {
+	q := &taskQuery{}
+
+	// $1 - текущий пользователь, на него ссылаются taskColumns, taskReadable и boardColumn
+	q.arg(*UserID)
+	q.where("t.deleted = false")
+	q.where(taskReadable)
+
+	if err = applyTaskFilter(q, filter); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %s,
+    		%s
+        FROM tasks t %s
+        WHERE %s
+        ORDER BY %s, t.id
+    `,
+		taskColumns,
+		boardColumn,
+		taskJoins,
+		strings.Join(q.conditions, "\n        	AND "),
+		taskPosition,
+	)
+
+	rows, err := db.Query(query, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	byColumn := make(map[string][]models.Task)
+	for rows.Next() {
+		var (
+			task   models.Task
+			column string
+		)
+		if err = scanTask(rows, &task, &column); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		byColumn[column] = append(byColumn[column], task)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	all, err := boardColumns(db, *UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns = []models.BoardColumn{}
+	for _, column := range all {
+		if len(filter.Statuses) > 0 && !containsString(filter.Statuses, column.Status) {
+			continue
+		}
+
+		key := column.Status
+		if column.State != "" {
+			key = column.State
+		}
+
+		column.Tasks = byColumn[key]
+		if column.Tasks == nil {
+			column.Tasks = []models.Task{}
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// boardColumns пустые колонки доски пользователя в порядке отображения
+func boardColumns(q querier, UserID string) ([]models.BoardColumn, error) {
+	rows, err := q.Query(`
+		SELECT name, category
+		FROM workflow_states
+		WHERE user_id = $1
+		ORDER BY position
+	`, UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	columns := []models.BoardColumn{}
+	for rows.Next() {
+		var column models.BoardColumn
+		if err = rows.Scan(&column.State, &column.Status); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		columns = append(columns, column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	if len(columns) == 0 {
+		for _, status := range models.BoardStatuses {
+			columns = append(columns, models.BoardColumn{Status: status})
+		}
+	}
+
+	return columns, nil
+}
+
+// MoveTaskDataBase переносит задачу в колонку между соседями атомарно.
+// Смена колонки меняет статус задачи так же, как переключение статуса, или состояние,
+// как переход по процессу; заблокированная задача завершается только с force.
+// Порядок задач у каждого пользователя свой.
+func MoveTaskDataBase(db *sql.DB, UserID *string, TaskID *string, move *models.TaskMove, force bool) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Перемещать задачу может владелец или редактор
+	if _, err = checkTaskAccess(tx, *UserID, *TaskID, models.RoleEditor, true); err != nil {
+		return err
+	}
+
+	var (
+		status, column string
+		state          sql.NullString
+	)
+	err = tx.QueryRow(`
+		SELECT t.status, `+boardColumn+`, (SELECT ws.name FROM workflow_states ws WHERE ws.id = t.state_id)
+		FROM tasks t
+		WHERE t.id = $2
+	`, *UserID, *TaskID).Scan(&status, &column, &state)
+	if err != nil {
+		return err
+	}
+
+	target := column
+	changed := false
+	switch {
+	case move.State != "":
+		// Состояния задачи - из процесса ее владельца, как и при переходе по процессу
+		var category string
+		err = tx.QueryRow(`
+			SELECT ws.category
+			FROM workflow_states ws
+			INNER JOIN tasks t ON t.user_id = ws.user_id
+			WHERE t.id = $1
+				AND ws.name = $2
+		`, *TaskID, move.State).Scan(&category)
+		if err == sql.ErrNoRows {
+			return ErrBoardColumnNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Колонка на доске перемещающего, как ее вычисляет boardColumn
+		target, err = defaultBoardColumn(tx, *UserID, move.State, category)
+		if err != nil {
+			return err
+		}
+		changed = move.State != state.String
+	case move.Status != "" && move.Status != status:
+		target, err = defaultBoardColumn(tx, *UserID, "", move.Status)
+		if err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	position, err := boardPosition(tx, *UserID, *TaskID, target, move)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		if move.State != "" {
+			err = transitionTask(tx, UserID, *TaskID, move.State, force)
+		} else {
+			_, err = setTaskStatus(tx, *TaskID, UserID, move.Status, force)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = setTaskPosition(tx, *UserID, *TaskID, position); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// defaultBoardColumn колонка пользователя, в которую попадает задача в состоянии state со статусом status:
+// одноименное состояние пользователя, иначе его состояние по умолчанию для статуса, без процесса - статус
+func defaultBoardColumn(tx *sql.Tx, UserID string, state string, status string) (column string, err error) {
+	err = tx.QueryRow(`
+		SELECT COALESCE(
+			(
+				SELECT name
+				FROM workflow_states
+				WHERE user_id = $1
+					AND name = $2
+					AND category = $3
+			),
+			(
+				SELECT name
+				FROM workflow_states
+				WHERE user_id = $1
+					AND category = $3
+				ORDER BY is_initial DESC, position
+				LIMIT 1
+			),
+			$3
+		)
+	`, UserID, state, status).Scan(&column)
+	return column, err
+}
+
+// setTaskPosition сохраняет позицию задачи на доске пользователя
+func setTaskPosition(tx *sql.Tx, UserID string, TaskID string, position float64) error {
+	_, err := tx.Exec(`
+		INSERT INTO task_positions (user_id, task_id, position)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, task_id) DO UPDATE
+		SET position = EXCLUDED.position
+	`, UserID, TaskID, position)
+	return err
+}
+
+// boardPosition вычисляет позицию задачи между соседями, при нехватке места
+// перенумеровывает колонку и повторяет расчет
+func boardPosition(tx *sql.Tx, UserID string, TaskID string, column string, move *models.TaskMove) (float64, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		after, err := neighborPosition(tx, UserID, column, move.AfterID)
+		if err != nil {
+			return 0, err
+		}
+		before, err := neighborPosition(tx, UserID, column, move.BeforeID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Без соседей задача встает в конец колонки
+		if after == nil && before == nil {
+			err = tx.QueryRow(`
+				SELECT max(`+taskPosition+`)
+				FROM tasks t `+taskAccessJoin+taskPositionJoin+`
+				WHERE t.deleted = false
+					AND `+taskReadable+`
+					AND `+boardColumn+` = $2
+					AND t.id <> $3
+			`, UserID, column, TaskID).Scan(&after)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if after != nil && before != nil && *after > *before {
+			return 0, ErrBoardNeighborsOrder
+		}
+
+		if position, ok := models.PositionBetween(after, before); ok {
+			return position, nil
+		}
+
+		if err = rebalanceBoardColumn(tx, UserID, column); err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, ErrBoardNeighborsOrder
+}
+
+// neighborPosition возвращает позицию соседней задачи в колонке column
+func neighborPosition(tx *sql.Tx, UserID string, column string, NeighborID *string) (*float64, error) {
+	if NeighborID == nil {
+		return nil, nil
+	}
+
+	var position float64
+	err := tx.QueryRow(`
+		SELECT `+taskPosition+`
+		FROM tasks t `+taskAccessJoin+taskPositionJoin+`
+		WHERE t.id = $2
+			AND t.deleted = false
+			AND `+taskReadable+`
+			AND `+boardColumn+` = $3
+	`, UserID, *NeighborID, column).Scan(&position)
+	if err == sql.ErrNoRows {
+		return nil, ErrBoardNeighborNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &position, nil
+}
+
+// rebalanceBoardColumn перенумеровывает задачи колонки целыми позициями.
+// Меняется только порядок текущего пользователя, позиции у других участников остаются прежними.
+func rebalanceBoardColumn(tx *sql.Tx, UserID string, column string) error {
+	_, err := tx.Exec(`
+		INSERT INTO task_positions (user_id, task_id, position)
+		SELECT $1, t.id, row_number() OVER (ORDER BY `+taskPosition+`, t.id)
+		FROM tasks t `+taskAccessJoin+taskPositionJoin+`
+		WHERE t.deleted = false
+			AND `+taskReadable+`
+			AND `+boardColumn+` = $2
+		ON CONFLICT (user_id, task_id) DO UPDATE
+		SET position = EXCLUDED.position
+	`, UserID, column)
+	return err
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// BulkTasksDataBase выполняет одно действие над списком задач в одной транзакции.
+// Задачи, которыми пользователь не владеет, и заблокированные задачи при завершении
+// без force пропускаются с ошибкой в результате.
+func BulkTasksDataBase(db *sql.DB, UserID *string, request *models.BulkTaskRequest, force bool) (results []models.BulkTaskResult, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем задачи в порядке ID, чтобы параллельные операции не взаимоблокировались.
+	// Все проверки проходят до изменений, поэтому результат не зависит от порядка в запросе.
+	locked := append([]string(nil), request.TaskIDs...)
+	sort.Strings(locked)
+
+	accessErrors := make(map[string]error, len(locked))
+	for _, id := range locked {
+		_, err = checkTaskAccess(tx, *UserID, id, models.RoleOwner, true)
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			accessErrors[id] = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	projectID := request.ProjectID
+	if projectID != nil && *projectID == "" {
+		projectID = nil
+	}
+	if request.Action == models.BulkActionMoveProject {
+		if err = checkTaskProject(tx, projectID, *UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	results = make([]models.BulkTaskResult, 0, len(request.TaskIDs))
+	for _, id := range request.TaskIDs {
+		if err := accessErrors[id]; err != nil {
+			results = append(results, models.BulkTaskResult{TaskID: id, Error: err.Error()})
+			continue
+		}
+
+		switch request.Action {
+		case models.BulkActionComplete:
+			_, err = setTaskStatus(tx, id, UserID, "completed", force)
+		case models.BulkActionReopen:
+			_, err = setTaskStatus(tx, id, UserID, "active", force)
+		case models.BulkActionDelete:
+			err = softDeleteTask(tx, id, *UserID)
+		case models.BulkActionSetPriority:
+			err = updateTaskField(tx, id, UserID, "priority", request.Priority)
+		case models.BulkActionSetDueDate:
+			var hadDueDate bool
+			err = tx.QueryRow("SELECT due_date IS NOT NULL FROM tasks WHERE id = $1", id).Scan(&hadDueDate)
+			if err == nil {
+				err = updateTaskField(tx, id, UserID, "due_date", request.DueDate)
+			}
+			if err == nil {
+				err = rearmReminders(tx, id)
+			}
+			if err == nil && !hadDueDate {
+				err = addDefaultReminder(tx, id)
+			}
+		case models.BulkActionMoveProject:
+			err = updateTaskField(tx, id, UserID, "project_id", projectID)
+		}
+		// Проверка блокировок идет до изменений, поэтому задачу можно просто пропустить
+		if errors.Is(err, ErrTaskBlocked) {
+			results = append(results, models.BulkTaskResult{TaskID: id, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, models.BulkTaskResult{TaskID: id, OK: true})
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// updateTaskField меняет одну колонку задачи и пишет изменение в историю.
+// column подставляется в запрос и должен быть константой.
+func updateTaskField(tx *sql.Tx, TaskID string, UserID *string, column string, value interface{}) error {
+	before, err := loadTaskSnapshot(tx, TaskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE tasks
+		SET `+column+` = $1,
+		    updated_at = now(),
+		    version = version + 1
+		WHERE id = $2
+	`, value, TaskID)
+	if err != nil {
+		return err
+	}
+
+	after, err := loadTaskSnapshot(tx, TaskID)
+	if err != nil {
+		return err
+	}
+
+	return recordTaskChanges(tx, TaskID, UserID, before, after)
+}
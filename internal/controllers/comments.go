@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("комментарий не найден")
+	ErrCommentForbidden = errors.New("можно изменять только свои комментарии")
+)
+
+const commentColumns = `
+		c.id,
+		c.task_id,
+		c.user_id,
+		u.login,
+		c.body,
+		c.updated_at > c.created_at,
+		c.created_at,
+		c.updated_at`
+
+func scanComment(row rowScanner, comment *models.Comment, extra ...interface{}) error {
+	dest := []interface{}{
+		&comment.ID,
+		&comment.TaskID,
+		&comment.UserID,
+		&comment.Login,
+		&comment.Body,
+		&comment.Edited,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	}
+
+	return row.Scan(append(dest, extra...)...)
+}
+
+// GetCommentsDataBase возвращает страницу комментариев задачи от старых к новым
+func GetCommentsDataBase(db *sql.DB, UserID *string, TaskID *string, limit int, cursor string) (comments []models.Comment, nextCursor string, err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return nil, "", err
+	}
+
+	q := &taskQuery{}
+	q.where("c.task_id = " + q.arg(*TaskID))
+
+	if cursor != "" {
+		position, err := models.DecodeTaskCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		q.where(fmt.Sprintf("(c.created_at, c.id) > (%s::timestamp, %s::uuid)", q.arg(position.Key), q.arg(position.ID)))
+	}
+
+	query := `
+		SELECT ` + commentColumns + `,
+			c.created_at::text
+		FROM task_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE ` + strings.Join(q.conditions, "\n			AND ") + `
+		ORDER BY c.created_at, c.id
+		LIMIT ` + q.arg(limit+1)
+
+	rows, err := db.Query(query, q.args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	comments = []models.Comment{}
+	var keys []string
+	for rows.Next() {
+		var (
+			comment models.Comment
+			key     string
+		)
+		if err = scanComment(rows, &comment, &key); err != nil {
+			return nil, "", fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		comments = append(comments, comment)
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := len(comments) - 1
+		nextCursor = models.EncodeTaskCursor(models.TaskCursor{Key: keys[last], ID: comments[last].ID})
+	}
+
+	return comments, nextCursor, nil
+}
+
+// CreateCommentDataBase добавляет комментарий; комментировать может любой участник задачи
+func CreateCommentDataBase(db *sql.DB, UserID *string, TaskID *string, comment *models.Comment) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return err
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO task_comments (task_id, user_id, body)
+			VALUES ($1, $2, $3)
+			RETURNING *
+		)
+		SELECT ` + commentColumns + `
+		FROM inserted c
+		INNER JOIN users u ON u.id = c.user_id
+	`
+
+	err = scanComment(db.QueryRow(query, *TaskID, *UserID, comment.Body), comment)
+	if err != nil {
+		return fmt.Errorf("ошибка создания комментария: %v", err)
+	}
+
+	return nil
+}
+
+// SaveCommentDataBase изменяет текст комментария его автором
+func SaveCommentDataBase(db *sql.DB, UserID *string, TaskID *string, CommentID *string, comment *models.Comment) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return err
+	}
+
+	if err = checkCommentAuthor(db, *UserID, *TaskID, *CommentID); err != nil {
+		return err
+	}
+
+	query := `
+		WITH updated AS (
+			UPDATE task_comments
+			SET body = $1,
+			    updated_at = now()
+			WHERE id = $2
+			RETURNING *
+		)
+		SELECT ` + commentColumns + `
+		FROM updated c
+		INNER JOIN users u ON u.id = c.user_id
+	`
+
+	err = scanComment(db.QueryRow(query, comment.Body, *CommentID), comment)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения комментария: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteCommentDataBase удаляет комментарий его автором
+func DeleteCommentDataBase(db *sql.DB, UserID *string, TaskID *string, CommentID *string) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return err
+	}
+
+	if err = checkCommentAuthor(db, *UserID, *TaskID, *CommentID); err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM task_comments WHERE id = $1", *CommentID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления комментария: %v", err)
+	}
+
+	return nil
+}
+
+func checkCommentAuthor(q querier, UserID string, TaskID string, CommentID string) error {
+	var authorID string
+	err := q.QueryRow("SELECT user_id FROM task_comments WHERE id = $1 AND task_id = $2", CommentID, TaskID).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if authorID != UserID {
+		return ErrCommentForbidden
+	}
+
+	return nil
+}
+
+// GetCommentRecipientsDataBase готовит уведомления о новом комментарии
+// для владельца и участников задачи, кроме автора комментария
+func GetCommentRecipientsDataBase(db *sql.DB, comment *models.Comment) (notifications []models.Notification, err error) {
+	query := `
+		SELECT u.id, u.email, t.title, t.priority, t.due_date, t.occurrence
+		FROM tasks t
+		INNER JOIN users u ON u.id = t.user_id
+		WHERE t.id = $1
+		UNION
+		SELECT u.id, u.email, t.title, t.priority, t.due_date, t.occurrence
+		FROM tasks t
+		INNER JOIN task_members tm ON tm.task_id = t.id
+		INNER JOIN users u ON u.id = tm.user_id
+		WHERE t.id = $1
+	`
+
+	rows, err := db.Query(query, comment.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		notification := models.Notification{
+			ID:         comment.ID,
+			Type:       models.NotificationTypeComment,
+			Task_id:    comment.TaskID,
+			Message:    comment.Body,
+			Created_at: comment.CreatedAt,
+		}
+
+		var email sql.NullString
+		err = rows.Scan(
+			&notification.User_id,
+			&email,
+			&notification.Title,
+			&notification.Priority,
+			&notification.Due_date,
+			&notification.Occurrence,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+
+		if notification.User_id == comment.UserID || email.String == "" {
+			continue
+		}
+		notification.Email = email.String
+
+		notifications = append(notifications, notification)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return notifications, nil
+}
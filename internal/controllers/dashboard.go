@@ -8,66 +8,63 @@ import (
 	"time"
 )
 
-func GetTasksDataBase(UserID *string, db *sql.DB) (tasks []models.Task, err error) {
+var (
+	ErrParentTaskNotFound  = errors.New("родительская задача не найдена")
+	ErrTaskVersionMismatch = errors.New("задача была изменена, обновите данные и повторите попытку")
+)
+
+func GetTasksDataBase(UserID *string, db *sql.DB, filter *models.TaskFilter) (tasks []models.Task, nextCursor string, err error) {
 	tasks = []models.Task{}
-	query := `
-        SELECT 
-            id,
-    		title,
-    		description,
-    		status,
-    		priority,
-    		due_date,
-    		created_at,
-    		updated_at
-        FROM tasks 
-        WHERE deleted = false
-        	AND user_id = $1
-        ORDER BY created_at DESC
-    `
-	rows, err := db.Query(query, *UserID)
+
+	query, args, err := buildTasksQuery(*UserID, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		fmt.Println(err)
-		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+		return nil, "", fmt.Errorf("ошибка запроса к БД: %v", err)
 	}
 	defer rows.Close()
 
+	var sortKeys []string
 	for rows.Next() {
-		var task models.Task
-		err = rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.Priority,
-			&task.DueDate,
-			&task.CreatedAt,
-			&task.UpdatedAt,
+		var (
+			task    models.Task
+			sortKey string
 		)
+		err = scanTask(rows, &task, &sortKey)
 		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+			return nil, "", fmt.Errorf("ошибка сканирования строки: %v", err)
 		}
 
 		tasks = append(tasks, task)
+		sortKeys = append(sortKeys, sortKey)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("ошибка чтения строк: %v", err)
 	}
 
-	return tasks, nil
+	// Лишняя задача означает, что есть следующая страница
+	if len(tasks) > filter.Limit {
+		tasks = tasks[:filter.Limit]
+		last := len(tasks) - 1
+		nextCursor = models.EncodeTaskCursor(models.TaskCursor{
+			Key:   sortKeys[last],
+			ID:    tasks[last].ID,
+			Sort:  filter.Sort,
+			Order: filter.Order,
+		})
+	}
+
+	return tasks, nextCursor, nil
 }
 
-func ToggleTaskStatusDataBase(db *sql.DB, taskID *string, UserID *string) (err error) {
-	var (
-		task_ID     string
-		task_Status string
-	)
-	query1 := `
-		SELECT 
-			id,
-			status
-		FROM tasks
-		WHERE
-			id = $1
-			and user_id = $2
-	`
+// ToggleTaskStatusDataBase переключает статус задачи. Задачу с незавершенными
+// блокирующими задачами можно завершить только с force.
+func ToggleTaskStatusDataBase(db *sql.DB, taskID *string, UserID *string, cascade bool, force bool) (err error) {
+	var task_Status string
 
 	tx, err := db.Begin()
 	if err != nil {
@@ -75,12 +72,13 @@ func ToggleTaskStatusDataBase(db *sql.DB, taskID *string, UserID *string) (err e
 	}
 	defer tx.Rollback()
 
-	// Проверяем наличие задачи у пользователя
-	err = tx.QueryRow(query1, *taskID, *UserID).Scan(&task_ID, &task_Status)
+	// Менять статус может владелец или редактор
+	if _, err = checkTaskAccess(tx, *UserID, *taskID, models.RoleEditor, true); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("SELECT status FROM tasks WHERE id = $1", *taskID).Scan(&task_Status)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return errors.New("задача не найдена")
-		}
 		return err
 	}
 
@@ -95,19 +93,30 @@ func ToggleTaskStatusDataBase(db *sql.DB, taskID *string, UserID *string) (err e
 		newStatus = "active"
 	}
 
-	query2 := `
-		UPDATE tasks
-		SET status = $1,
-		    updated_at = now()
-		WHERE id = $2
-	`
-
 	// Обновляем статус задачи
-	_, err = tx.Exec(query2, newStatus, *taskID)
-	if err != nil {
+	if _, err = setTaskStatus(tx, *taskID, UserID, newStatus, force); err != nil {
 		return err
 	}
 
+	// Завершаем все подзадачи вместе с родительской так же, как саму задачу:
+	// с историей и следующим повторением. Без force заблокированные подзадачи остаются незавершенными.
+	if cascade && newStatus == "completed" {
+		subtasks, err := taskSubtree(tx, *taskID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range subtasks {
+			_, err = setTaskStatus(tx, id, UserID, "completed", force)
+			if errors.Is(err, ErrTaskBlocked) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Подтверждаем транзакцию
 	if err = tx.Commit(); err != nil {
 		return err
@@ -116,41 +125,206 @@ func ToggleTaskStatusDataBase(db *sql.DB, taskID *string, UserID *string) (err e
 	return nil
 }
 
-func DeleteTaskDataBase(db *sql.DB, taskID *string, UserID *string) (err error) {
-	query2 := `
+// taskSubtree возвращает неудаленные подзадачи всех уровней, родительские раньше дочерних
+func taskSubtree(tx *sql.Tx, TaskID string) (ids []string, err error) {
+	rows, err := tx.Query(`
+		WITH RECURSIVE subtree AS (
+			SELECT id, 1 AS depth FROM tasks WHERE parent_id = $1 AND deleted = false
+			UNION ALL
+			SELECT t.id, s.depth + 1 FROM tasks t
+			INNER JOIN subtree s ON t.parent_id = s.id
+			WHERE t.deleted = false
+		)
+		SELECT id FROM subtree ORDER BY depth, id
+	`, TaskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// setTaskStatus меняет статус задачи и пишет изменение в историю.
+// Задачу с незавершенными блокирующими задачами можно завершить только с force.
+// Завершение повторяющейся задачи создает следующее повторение.
+func setTaskStatus(tx *sql.Tx, TaskID string, UserID *string, newStatus string, force bool) (changed bool, err error) {
+	var oldStatus string
+	err = tx.QueryRow("SELECT status FROM tasks WHERE id = $1", TaskID).Scan(&oldStatus)
+	if err != nil {
+		return false, err
+	}
+	if oldStatus == newStatus {
+		return false, nil
+	}
+
+	if newStatus == "completed" && !force {
+		if err = checkTaskBlockers(tx, TaskID); err != nil {
+			return false, err
+		}
+	}
+
+	before, err := loadTaskSnapshot(tx, TaskID)
+	if err != nil {
+		return false, err
+	}
+
+	// Состояние процесса следует за статусом: начальное для active, первое завершающее для completed
+	query := `
 		UPDATE tasks
-		SET deleted = true
-		WHERE id = $1
-			AND user_id = $2
+		SET status = $1,
+		    state_id = ` + workflowStateFor("tasks", "$1") + `,
+		    updated_at = now(),
+		    version = version + 1
+		WHERE id = $2
 	`
 
-	// Проставляем флаг удаления задачи
-	result, err := db.Exec(query2, *taskID, *UserID)
+	if _, err = tx.Exec(query, newStatus, TaskID); err != nil {
+		return false, err
+	}
+
+	after, err := loadTaskSnapshot(tx, TaskID)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if err = recordTaskChanges(tx, TaskID, UserID, before, after); err != nil {
+		return false, err
+	}
+
+	// Завершенная повторяющаяся задача порождает следующее повторение
+	if newStatus == "completed" {
+		if err = createNextOccurrence(tx, TaskID); err != nil {
+			return false, err
+		}
 	}
 
-	//Проверяем наличие задачи
-	count, err := result.RowsAffected()
+	return true, nil
+}
+
+func DeleteTaskDataBase(db *sql.DB, taskID *string, UserID *string) (err error) {
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	// Удалить задачу может только владелец
+	if _, err = checkTaskAccess(tx, *UserID, *taskID, models.RoleOwner, true); err != nil {
+		return err
+	}
+
+	if err = softDeleteTask(tx, *taskID, *UserID); err != nil {
+		return err
+	}
 
-	if count == 0 {
-		return errors.New("задача не найдена")
+	if err = tx.Commit(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func CreateTaskDataBase(db *sql.DB, taskData *models.Task) (err error) {
+// softDeleteTask переносит задачу и все её подзадачи в корзину
+func softDeleteTask(tx *sql.Tx, TaskID string, UserID string) error {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE id = $1 AND deleted = false
+			UNION ALL
+			SELECT t.id FROM tasks t
+			INNER JOIN subtree s ON t.parent_id = s.id
+			WHERE t.deleted = false
+		), deleted AS (
+			UPDATE tasks
+			SET deleted = true,
+			    deleted_at = now(),
+			    version = version + 1
+			WHERE id IN (SELECT id FROM subtree)
+			RETURNING id
+		)
+		INSERT INTO task_events (task_id, user_id, action)
+		SELECT id, $2::uuid, 'deleted'
+		FROM deleted
+	`
+
+	// Проставляем флаг удаления задаче и всем её подзадачам
+	_, err := tx.Exec(query, TaskID, UserID)
+	return err
+}
+
+// CreateTaskDataBase создает задачу и записывает её ID в taskData.
+// Если передан idempotencyKey и задача по нему уже создана, новая задача не создается:
+// в taskData записывается ID существующей задачи и возвращается replayed = true.
+func CreateTaskDataBase(db *sql.DB, taskData *models.Task, idempotencyKey string, requestHash string) (replayed bool, err error) {
+	// Подзадачу может создать владелец или редактор родительской задачи.
+	// Подзадача принадлежит владельцу родительской задачи.
+	creatorID := taskData.UserID
+	if taskData.ParentID != nil {
+		_, err = checkTaskAccess(db, creatorID, *taskData.ParentID, models.RoleEditor, false)
+		if err == ErrTaskNotFound {
+			return false, ErrParentTaskNotFound
+		}
+		if err != nil {
+			return false, err
+		}
+
+		// Без явного проекта подзадача попадает в проект родительской задачи
+		var parentProjectID *string
+		err = db.QueryRow("SELECT user_id, project_id FROM tasks WHERE id = $1", *taskData.ParentID).
+			Scan(&taskData.UserID, &parentProjectID)
+		if err != nil {
+			return false, err
+		}
+		if taskData.ProjectID == nil {
+			taskData.ProjectID = parentProjectID
+		}
+	}
+
+	if err = checkTaskProject(db, taskData.ProjectID, taskData.UserID); err != nil {
+		return false, err
+	}
+
+	recurrence, err := recurrenceValue(taskData.Recurrence)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Повтор запроса с тем же ключом возвращает уже созданную задачу
+	if idempotencyKey != "" {
+		existingID, err := claimIdempotencyKey(tx, creatorID, idempotencyKey, requestHash)
+		if err != nil {
+			return false, err
+		}
+		if existingID != "" {
+			taskData.ID = existingID
+			return true, nil
+		}
+	}
+
 	query := `
-		INSERT INTO tasks (user_id, deleted, title, description, status, priority, due_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO tasks (user_id, deleted, title, description, status, priority, due_date, parent_id, project_id, recurrence, state_id, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			(SELECT id FROM workflow_states WHERE user_id = $1 AND is_initial),
+			` + newTaskPosition + `,
+			$11, $12)
+		RETURNING id
 	`
 
 	// Вставляем новую задачу в БД
-	_, err = db.Exec(query,
+	err = tx.QueryRow(query,
 		taskData.UserID,
 		false,
 		taskData.Title,
@@ -158,47 +332,75 @@ func CreateTaskDataBase(db *sql.DB, taskData *models.Task) (err error) {
 		"active",
 		taskData.Priority,
 		taskData.DueDate,
+		taskData.ParentID,
+		taskData.ProjectID,
+		recurrence,
 		time.Now(),
-		time.Now())
+		time.Now()).Scan(&taskData.ID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	if len(taskData.Tags) > 0 {
+		if err = setTaskTags(tx, taskData.ID, taskData.UserID, taskData.Tags); err != nil {
+			return false, err
+		}
+	}
+
+	if err = recordTaskEvent(tx, taskData.ID, &creatorID, models.TaskEventCreated); err != nil {
+		return false, err
+	}
+
+	if idempotencyKey != "" {
+		if err = completeIdempotencyKey(tx, creatorID, idempotencyKey, taskData.ID); err != nil {
+			return false, err
+		}
+	}
+
+	// Редактор чужой задачи сохраняет доступ к созданной им подзадаче
+	if creatorID != taskData.UserID {
+		_, err = tx.Exec(`
+			INSERT INTO task_members (task_id, user_id, role)
+			VALUES ($1, $2, $3)
+		`, taskData.ID, creatorID, models.RoleEditor)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Задача со сроком по умолчанию получает напоминание за сутки
+	if taskData.DueDate != nil {
+		_, err = tx.Exec(`
+			INSERT INTO reminders (task_id, offset_minutes)
+			VALUES ($1, $2)
+		`, taskData.ID, models.DefaultReminderOffset)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return false, nil
 }
 
 func GetTaskDataBase(db *sql.DB, UserID *string, TaskId *string) (taskData models.Task, err error) {
 	taskData = models.Task{}
 	query := `
-        SELECT 
-            id,
-    		title,
-    		description,
-    		status,
-    		priority,
-    		due_date,
-    		created_at,
-    		updated_at
-        FROM tasks 
-        WHERE deleted = false
-        	and user_id = $1
-        	and id = $2
+        SELECT ` + taskColumns + `
+        FROM tasks t ` + taskJoins + `
+        WHERE t.deleted = false
+        	and ` + taskReadable + `
+        	and t.id = $2
     `
 
 	// Получение задачи и БД
-	err = db.QueryRow(query, *UserID, *TaskId).Scan(
-		&taskData.ID,
-		&taskData.Title,
-		&taskData.Description,
-		&taskData.Status,
-		&taskData.Priority,
-		&taskData.DueDate,
-		&taskData.CreatedAt,
-		&taskData.UpdatedAt,
-	)
+	err = scanTask(db.QueryRow(query, *UserID, *TaskId), &taskData)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return taskData, errors.New("задача не найдена")
+			return taskData, ErrTaskNotFound
 		}
 		return taskData, fmt.Errorf("ошибка запроса к БД: %v", err)
 	}
@@ -206,38 +408,141 @@ func GetTaskDataBase(db *sql.DB, UserID *string, TaskId *string) (taskData model
 	return taskData, nil
 }
 
-func SavaTaskDB(db *sql.DB, UserID *string, TaskID *string, newTaskData *models.Task) (err error) {
+func GetSubtasksDataBase(db *sql.DB, UserID *string, TaskID *string) (tasks []models.Task, err error) {
+	// Проверяем, что родительская задача принадлежит пользователю
+	if _, err = GetTaskDataBase(db, UserID, TaskID); err != nil {
+		return nil, err
+	}
+
+	tasks = []models.Task{}
+	query := `
+        SELECT ` + taskColumns + `
+        FROM tasks t ` + taskJoins + `
+        WHERE t.deleted = false
+        	AND ` + taskReadable + `
+        	AND t.parent_id = $2
+        ORDER BY t.created_at, t.id
+    `
+
+	rows, err := db.Query(query, *UserID, *TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task models.Task
+		if err = scanTask(rows, &task); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return tasks, nil
+}
+
+// SavaTaskDB сохраняет редактируемые поля задачи.
+// Если expectedVersion задан, задача сохраняется, только если её версия не изменилась.
+func SavaTaskDB(db *sql.DB, UserID *string, TaskID *string, newTaskData *models.Task, expectedVersion *int) (err error) {
+	recurrence, err := recurrenceValue(newTaskData.Recurrence)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Изменять задачу может владелец или редактор
+	if _, err = checkTaskAccess(tx, *UserID, *TaskID, models.RoleEditor, true); err != nil {
+		return err
+	}
+
+	// Проект задачи должен принадлежать её владельцу
+	var (
+		ownerID    string
+		version    int
+		hadDueDate bool
+	)
+	err = tx.QueryRow("SELECT user_id, version, due_date IS NOT NULL FROM tasks WHERE id = $1", *TaskID).
+		Scan(&ownerID, &version, &hadDueDate)
+	if err != nil {
+		return err
+	}
+	if expectedVersion != nil && *expectedVersion != version {
+		return ErrTaskVersionMismatch
+	}
+	if err = checkTaskProject(tx, newTaskData.ProjectID, ownerID); err != nil {
+		return err
+	}
+
+	before, err := loadTaskSnapshot(tx, *TaskID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE tasks
 		SET 
 		    title = $1,
 		    description = $2,
 		    priority = $3,
-		    due_date = $4
+		    due_date = $4,
+		    recurrence = $5,
+		    project_id = $6,
+		    updated_at = now(),
+		    version = version + 1
 		WHERE deleted = false
-		  	AND user_id = $5 
-			AND id = $6
+			AND id = $7
 	`
 
-	result, err := db.Exec(query,
+	_, err = tx.Exec(query,
 		newTaskData.Title,
 		newTaskData.Description,
 		newTaskData.Priority,
 		newTaskData.DueDate,
-		*UserID,
+		recurrence,
+		newTaskData.ProjectID,
 		*TaskID,
 	)
 	if err != nil {
 		return err
 	}
 
-	// Проверяем наличие задачи
-	count, err := result.RowsAffected()
+	// Теги меняются, только если переданы в запросе
+	if newTaskData.Tags != nil {
+		if err = setTaskTags(tx, *TaskID, ownerID, newTaskData.Tags); err != nil {
+			return err
+		}
+	}
+
+	after, err := loadTaskSnapshot(tx, *TaskID)
 	if err != nil {
 		return err
 	}
-	if count == 0 {
-		return errors.New("задача не найдена")
+	if err = recordTaskChanges(tx, *TaskID, UserID, before, after); err != nil {
+		return err
+	}
+
+	// Срок мог сдвинуться, напоминания до него нужно отправить заново
+	if err = rearmReminders(tx, *TaskID); err != nil {
+		return err
+	}
+
+	// Задача, у которой появился срок, получает напоминание за сутки, как при создании
+	if !hadDueDate {
+		if err = addDefaultReminder(tx, *TaskID); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
 	}
 
 	return nil
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBlockerNotFound    = errors.New("блокирующая задача не найдена")
+	ErrDependencyCycle    = errors.New("зависимость создаст цикл")
+	ErrDependencyNotFound = errors.New("зависимость не найдена")
+	ErrTaskBlocked        = errors.New("задача заблокирована незавершенными задачами")
+)
+
+// GetTaskDependenciesDataBase возвращает видимые пользователю блокирующие и ожидающие задачи
+func GetTaskDependenciesDataBase(db *sql.DB, UserID *string, TaskID *string) (dependencies models.TaskDependencies, err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return dependencies, err
+	}
+
+	dependencies.BlockedBy, err = getDependencyTasks(db, *UserID, `
+		SELECT blocker_id FROM task_dependencies WHERE task_id = $2`, *TaskID)
+	if err != nil {
+		return dependencies, err
+	}
+
+	dependencies.Blocks, err = getDependencyTasks(db, *UserID, `
+		SELECT task_id FROM task_dependencies WHERE blocker_id = $2`, *TaskID)
+	if err != nil {
+		return dependencies, err
+	}
+
+	return dependencies, nil
+}
+
+// getDependencyTasks читает задачи, ID которых выбирает подзапрос idsQuery с параметром $2
+func getDependencyTasks(db *sql.DB, UserID string, idsQuery string, TaskID string) ([]models.Task, error) {
+	tasks := []models.Task{}
+
+	rows, err := db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks t `+taskJoins+`
+		WHERE t.deleted = false
+			AND `+taskReadable+`
+			AND t.id IN (`+idsQuery+`)
+		ORDER BY t.created_at, t.id
+	`, UserID, TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task models.Task
+		if err = scanTask(rows, &task); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return tasks, nil
+}
+
+// AddTaskDependencyDataBase делает задачу TaskID зависимой от BlockerID.
+// Повторное добавление существующей зависимости ничего не меняет.
+func AddTaskDependencyDataBase(db *sql.DB, UserID *string, TaskID *string, BlockerID *string) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Менять зависимости может владелец или редактор задачи
+	if _, err = checkTaskAccess(tx, *UserID, *TaskID, models.RoleEditor, true); err != nil {
+		return err
+	}
+
+	// Блокирующую задачу достаточно видеть
+	if _, err = checkTaskAccess(tx, *UserID, *BlockerID, models.RoleViewer, false); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return ErrBlockerNotFound
+		}
+		return err
+	}
+
+	// Параллельные вставки могли бы вместе образовать цикл, поэтому изменения зависимостей идут по очереди
+	if _, err = tx.Exec("LOCK TABLE task_dependencies IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	// Цикл возникнет, если BlockerID уже прямо или косвенно ждет TaskID
+	var cycle bool
+	err = tx.QueryRow(`
+		WITH RECURSIVE chain AS (
+			SELECT blocker_id FROM task_dependencies WHERE task_id = $1
+			UNION
+			SELECT d.blocker_id
+			FROM task_dependencies d
+			INNER JOIN chain c ON d.task_id = c.blocker_id
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE blocker_id = $2)
+	`, *BlockerID, *TaskID).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO task_dependencies (task_id, blocker_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, *TaskID, *BlockerID)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления зависимости: %v", err)
+	}
+
+	if added, _ := result.RowsAffected(); added > 0 {
+		if err = recordTaskChange(tx, *TaskID, UserID, "blocked_by", nil, BlockerID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTaskDependencyDataBase убирает зависимость задачи TaskID от BlockerID
+func DeleteTaskDependencyDataBase(db *sql.DB, UserID *string, TaskID *string, BlockerID *string) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = checkTaskAccess(tx, *UserID, *TaskID, models.RoleEditor, true); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM task_dependencies
+		WHERE task_id = $1
+			AND blocker_id = $2
+	`, *TaskID, *BlockerID)
+	if err != nil {
+		return err
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return ErrDependencyNotFound
+	}
+
+	if err = recordTaskChange(tx, *TaskID, UserID, "blocked_by", BlockerID, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkTaskBlockers возвращает ErrTaskBlocked, если у задачи есть незавершенные блокирующие задачи
+func checkTaskBlockers(q querier, TaskID string) error {
+	var blocked bool
+	err := q.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM task_dependencies d
+			INNER JOIN tasks b ON b.id = d.blocker_id
+			WHERE d.task_id = $1
+				AND b.deleted = false
+				AND b.status <> 'completed'
+		)
+	`, TaskID).Scan(&blocked)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrTaskBlocked
+	}
+
+	return nil
+}
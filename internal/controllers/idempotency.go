@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrIdempotencyKeyReused = errors.New("ключ идемпотентности уже использован для другого запроса")
+	ErrIdempotentTaskGone   = errors.New("задача, созданная по ключу идемпотентности, окончательно удалена")
+)
+
+// idempotencyKeyTTL сколько хранится ключ идемпотентности
+const idempotencyKeyTTL = "24 hours"
+
+// FindIdempotentTaskDataBase ищет задачу, уже созданную по ключу, не занимая ключ.
+// Повтор отвечает созданной задачей, даже если проверки исходного запроса теперь не прошли бы
+// (родительская задача или проект удалены). Пустой ID - ключ еще не использован.
+func FindIdempotentTaskDataBase(db *sql.DB, UserID string, key string, requestHash string) (string, error) {
+	var (
+		storedHash string
+		taskID     sql.NullString
+	)
+	err := db.QueryRow(`
+		SELECT request_hash, task_id
+		FROM idempotency_keys
+		WHERE user_id = $1
+			AND key = $2
+			AND created_at >= now() - $3::interval
+	`, UserID, key, idempotencyKeyTTL).Scan(&storedHash, &taskID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return ReplayIdempotencyKey(storedHash, taskID, requestHash)
+}
+
+// claimIdempotencyKey занимает ключ в транзакции создания задачи.
+// Если ключ уже использован тем же запросом, возвращает ID созданной по нему задачи.
+// Параллельный запрос с тем же ключом ждет завершения первой транзакции.
+func claimIdempotencyKey(tx *sql.Tx, UserID string, key string, requestHash string) (existingTaskID string, err error) {
+	_, err = tx.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1
+			AND created_at < now() - $2::interval
+	`, UserID, idempotencyKeyTTL)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO idempotency_keys (user_id, key, request_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, key) DO NOTHING
+	`, UserID, key, requestHash)
+	if err != nil {
+		return "", err
+	}
+
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 1 {
+		return "", err
+	}
+
+	var (
+		storedHash string
+		taskID     sql.NullString
+	)
+	err = tx.QueryRow(`
+		SELECT request_hash, task_id
+		FROM idempotency_keys
+		WHERE user_id = $1
+			AND key = $2
+	`, UserID, key).Scan(&storedHash, &taskID)
+	if err != nil {
+		return "", err
+	}
+
+	return ReplayIdempotencyKey(storedHash, taskID, requestHash)
+}
+
+// ReplayIdempotencyKey отвечает на повтор запроса по уже занятому ключу: ID задачи, созданной исходным запросом.
+// Ключ хранит task_id до конца транзакции создания, поэтому пустой task_id значит, что задачу удалили из корзины.
+func ReplayIdempotencyKey(storedHash string, taskID sql.NullString, requestHash string) (string, error) {
+	if storedHash != requestHash {
+		return "", ErrIdempotencyKeyReused
+	}
+	if !taskID.Valid {
+		return "", ErrIdempotentTaskGone
+	}
+
+	return taskID.String, nil
+}
+
+// completeIdempotencyKey связывает ключ с созданной задачей
+func completeIdempotencyKey(tx *sql.Tx, UserID string, key string, TaskID string) error {
+	_, err := tx.Exec(`
+		UPDATE idempotency_keys
+		SET task_id = $3
+		WHERE user_id = $1
+			AND key = $2
+	`, UserID, key, TaskID)
+	return err
+}
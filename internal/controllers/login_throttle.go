@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Событие журнала: вход заблокирован после серии неудач
+const LoginAuditLockout = "lockout"
+
+// loginThrottleSubject область учета попыток входа
+type loginThrottleSubject struct {
+	scope   string
+	subject string
+}
+
+func loginThrottleSubjects(login string, ip string) []loginThrottleSubject {
+	// Порядок блокировки строк одинаковый во всех транзакциях
+	return []loginThrottleSubject{
+		{models.ThrottleScopeLogin, login},
+		{models.ThrottleScopeIP, ip},
+	}
+}
+
+// ReserveLoginAttemptDataBase занимает логин и адрес под попытку входа.
+// Строки счетчиков блокируются, поэтому из параллельных попыток проходит одна,
+// остальные получают время ожидания. Ноль - попытка разрешена и занята броней reservation.
+func ReserveLoginAttemptDataBase(db *sql.DB, policy models.LoginThrottlePolicy, login string, ip string) (reservation models.LoginReservation, wait time.Duration, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	// PostgreSQL хранит время с точностью до микросекунд, а бронь потом сравнивается на равенство
+	now := time.Now().Truncate(time.Microsecond)
+
+	// Заодно убираем забытые записи
+	_, err = tx.Exec(`
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1
+			AND (blocked_until IS NULL OR blocked_until < $2)
+	`, now.Add(-policy.Window), now)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	subjects := loginThrottleSubjects(login, ip)
+	throttles := make([]models.LoginThrottle, len(subjects))
+	for i, s := range subjects {
+		if throttles[i], err = lockLoginThrottle(tx, s, now); err != nil {
+			return nil, 0, err
+		}
+		if w := throttles[i].Wait(now); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return nil, wait, nil
+	}
+
+	reservation = models.LoginReservation{}
+	for i, s := range subjects {
+		throttles[i].Reserve(now, policy, s.scope)
+		if err = saveLoginThrottle(tx, s, throttles[i]); err != nil {
+			return nil, 0, err
+		}
+		reservation[s.scope] = throttles[i].BlockedUntil
+	}
+
+	return reservation, 0, tx.Commit()
+}
+
+// RecordLoginFailureDataBase учитывает неудачную попытку по логину и по адресу:
+// назначает экспоненциальную задержку, а при достижении порога - блокировку с записью в журнал
+func RecordLoginFailureDataBase(db *sql.DB, policy models.LoginThrottlePolicy, login string, ip string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, s := range loginThrottleSubjects(login, ip) {
+		throttle, err := lockLoginThrottle(tx, s, now)
+		if err != nil {
+			return err
+		}
+
+		locked := throttle.Fail(now, policy, s.scope)
+		if err = saveLoginThrottle(tx, s, throttle); err != nil {
+			return err
+		}
+
+		if !locked {
+			continue
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO login_audit_log (event, scope, subject, ip, failures, locked_until)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, LoginAuditLockout, s.scope, s.subject, ip, throttle.Failures, throttle.BlockedUntil)
+		if err != nil {
+			return fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+
+		log.Printf("Вход заблокирован до %s: %s %q, неудачных попыток %d, адрес %s",
+			throttle.BlockedUntil.Format(time.RFC3339), s.scope, s.subject, throttle.Failures, ip)
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseLoginAttemptDataBase освобождает логин и адрес после удачного шага входа,
+// счетчики неудач сохраняются: вход еще не завершен вторым фактором.
+// Снимается только своя бронь: после ее истечения область могли занять или заблокировать другие попытки.
+func ReleaseLoginAttemptDataBase(db *sql.DB, login string, ip string, reservation models.LoginReservation) error {
+	for _, s := range loginThrottleSubjects(login, ip) {
+		reserved, ok := reservation[s.scope]
+		if !ok {
+			continue
+		}
+
+		_, err := db.Exec(`
+			UPDATE login_throttles
+			SET blocked_until = NULL
+			WHERE scope = $1
+				AND subject = $2
+				AND blocked_until = $3
+		`, s.scope, s.subject, reserved)
+		if err != nil {
+			return fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ResetLoginThrottleDataBase сбрасывает счетчик логина после полностью успешного входа и освобождает адрес.
+// Счетчик адреса не сбрасывается: иначе перебор чужих логинов можно чередовать со входом в свой.
+func ResetLoginThrottleDataBase(db *sql.DB, login string, ip string, reservation models.LoginReservation) error {
+	if err := ReleaseLoginAttemptDataBase(db, login, ip, reservation); err != nil {
+		return err
+	}
+
+	_, err := db.Exec("DELETE FROM login_throttles WHERE scope = $1 AND subject = $2",
+		models.ThrottleScopeLogin, login)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return nil
+}
+
+// lockLoginThrottle читает счетчик области с блокировкой строки, создавая его при отсутствии
+func lockLoginThrottle(tx *sql.Tx, s loginThrottleSubject, now time.Time) (throttle models.LoginThrottle, err error) {
+	_, err = tx.Exec(`
+		INSERT INTO login_throttles (scope, subject, failures, last_failure_at)
+		VALUES ($1, $2, 0, $3)
+		ON CONFLICT (scope, subject) DO NOTHING
+	`, s.scope, s.subject, now)
+	if err != nil {
+		return throttle, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	var blockedUntil sql.NullTime
+	err = tx.QueryRow(`
+		SELECT failures, last_failure_at, blocked_until
+		FROM login_throttles
+		WHERE scope = $1
+			AND subject = $2
+		FOR UPDATE
+	`, s.scope, s.subject).Scan(&throttle.Failures, &throttle.LastFailureAt, &blockedUntil)
+	if err != nil {
+		return throttle, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if blockedUntil.Valid {
+		throttle.BlockedUntil = blockedUntil.Time
+	}
+
+	return throttle, nil
+}
+
+// saveLoginThrottle сохраняет счетчик области
+func saveLoginThrottle(tx *sql.Tx, s loginThrottleSubject, throttle models.LoginThrottle) error {
+	var blockedUntil sql.NullTime
+	if !throttle.BlockedUntil.IsZero() {
+		blockedUntil = sql.NullTime{Time: throttle.BlockedUntil, Valid: true}
+	}
+
+	_, err := tx.Exec(`
+		UPDATE login_throttles
+		SET failures = $3,
+		    last_failure_at = $4,
+		    blocked_until = $5
+		WHERE scope = $1
+			AND subject = $2
+	`, s.scope, s.subject, throttle.Failures, throttle.LastFailureAt, blockedUntil)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var ErrProjectNotFound = errors.New("проект не найден")
+
+const projectColumns = `
+		p.id,
+		p.user_id,
+		p.name,
+		p.color,
+		p.archived,
+		(SELECT count(*) FROM tasks t WHERE t.project_id = p.id AND t.deleted = false),
+		(SELECT count(*) FROM tasks t WHERE t.project_id = p.id AND t.deleted = false AND t.status = 'completed'),
+		p.created_at,
+		p.updated_at`
+
+func scanProject(row rowScanner, project *models.Project) error {
+	return row.Scan(
+		&project.ID,
+		&project.UserID,
+		&project.Name,
+		&project.Color,
+		&project.Archived,
+		&project.TasksTotal,
+		&project.TasksCompleted,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	)
+}
+
+func GetProjectsDataBase(db *sql.DB, UserID *string, includeArchived bool) (projects []models.Project, err error) {
+	projects = []models.Project{}
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects p
+		WHERE p.user_id = $1
+			AND ($2 OR p.archived = false)
+		ORDER BY p.archived, p.name, p.id
+	`
+
+	rows, err := db.Query(query, *UserID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var project models.Project
+		if err = scanProject(rows, &project); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		projects = append(projects, project)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return projects, nil
+}
+
+func GetProjectDataBase(db *sql.DB, UserID *string, ProjectID *string) (project models.Project, err error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects p
+		WHERE p.user_id = $1
+			AND p.id = $2
+	`
+
+	err = scanProject(db.QueryRow(query, *UserID, *ProjectID), &project)
+	if err == sql.ErrNoRows {
+		return project, ErrProjectNotFound
+	}
+	if err != nil {
+		return project, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return project, nil
+}
+
+func CreateProjectDataBase(db *sql.DB, project *models.Project) (err error) {
+	query := `
+		INSERT INTO projects (user_id, name, color, archived)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = db.QueryRow(query, project.UserID, project.Name, project.Color, project.Archived).
+		Scan(&project.ID, &project.CreatedAt, &project.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка создания проекта: %v", err)
+	}
+
+	return nil
+}
+
+func SaveProjectDataBase(db *sql.DB, UserID *string, ProjectID *string, project *models.Project) (err error) {
+	query := `
+		UPDATE projects
+		SET name = $1,
+		    color = $2,
+		    archived = $3,
+		    updated_at = now()
+		WHERE user_id = $4
+			AND id = $5
+	`
+
+	result, err := db.Exec(query, project.Name, project.Color, project.Archived, *UserID, *ProjectID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// DeleteProjectDataBase удаляет проект, задачи остаются без проекта
+func DeleteProjectDataBase(db *sql.DB, UserID *string, ProjectID *string) (err error) {
+	result, err := db.Exec(`
+		DELETE FROM projects
+		WHERE user_id = $1
+			AND id = $2
+	`, *UserID, *ProjectID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// checkTaskProject проверяет, что проект принадлежит владельцу задачи
+func checkTaskProject(q querier, ProjectID *string, OwnerID string) error {
+	if ProjectID == nil {
+		return nil
+	}
+
+	var count int
+	err := q.QueryRow(`
+		SELECT count(*)
+		FROM projects
+		WHERE id = $1
+			AND user_id = $2
+	`, *ProjectID, OwnerID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// recurrenceValue готовит правило повторения для записи в колонку JSONB
+func recurrenceValue(recurrence *models.Recurrence) (interface{}, error) {
+	if recurrence == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации правила повторения: %v", err)
+	}
+
+	return string(data), nil
+}
+
+// createNextOccurrence создает следующее повторение завершенной задачи,
+// если у задачи есть правило повторения и оно еще не исчерпано
+func createNextOccurrence(tx *sql.Tx, taskID string) error {
+	var (
+		recurrenceData []byte
+		dueDate        sql.NullTime
+		seriesID       string
+		occurrence     int
+		timezone       string
+		ownerID        string
+	)
+
+	err := tx.QueryRow(`
+		SELECT t.recurrence, t.due_date, COALESCE(t.series_id, t.id), t.occurrence, u.timezone, t.user_id
+		FROM tasks t
+		INNER JOIN users u ON u.id = t.user_id
+		WHERE t.id = $1
+	`, taskID).Scan(&recurrenceData, &dueDate, &seriesID, &occurrence, &timezone, &ownerID)
+	if err != nil {
+		return err
+	}
+
+	if recurrenceData == nil || !dueDate.Valid {
+		return nil
+	}
+
+	var recurrence models.Recurrence
+	if err = json.Unmarshal(recurrenceData, &recurrence); err != nil {
+		return fmt.Errorf("ошибка чтения правила повторения: %v", err)
+	}
+
+	// Дни недели и месяца считаем в часовом поясе пользователя
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	// Правила, сохраненные без month_day, привязываем к сроку первого повторения серии
+	if recurrence.Freq == "monthly" && recurrence.MonthDay == 0 {
+		var firstDue time.Time
+		err = tx.QueryRow(`
+			SELECT due_date
+			FROM tasks
+			WHERE (id = $1 OR series_id = $1)
+				AND due_date IS NOT NULL
+			ORDER BY occurrence
+			LIMIT 1
+		`, seriesID).Scan(&firstDue)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения первого повторения: %v", err)
+		}
+		recurrence.MonthDay = firstDue.In(loc).Day()
+	}
+
+	next, ok := recurrence.Next(dueDate.Time.In(loc), occurrence)
+	if !ok {
+		return nil
+	}
+
+	// Повторение могло быть создано раньше, если задачу переоткрывали
+	var count int
+	err = tx.QueryRow(`
+		SELECT count(*)
+		FROM tasks
+		WHERE series_id = $1
+			AND occurrence > $2
+	`, seriesID, occurrence).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// Первое повторение открывает серию
+	_, err = tx.Exec(`
+		UPDATE tasks
+		SET series_id = $1
+		WHERE id = $2
+			AND series_id IS NULL
+	`, seriesID, taskID)
+	if err != nil {
+		return err
+	}
+
+	var nextID string
+	err = tx.QueryRow(`
+		INSERT INTO tasks (user_id, deleted, title, description, status, priority, due_date, parent_id, project_id, recurrence, series_id, occurrence, state_id, position, created_at, updated_at)
+		SELECT s.user_id, false, s.title, s.description, 'active', s.priority, $3, s.parent_id, s.project_id, s.recurrence, $4, $5,
+			(SELECT ws.id FROM workflow_states ws WHERE ws.user_id = s.user_id AND ws.is_initial),
+			`+newTaskPosition+`,
+			now(), now()
+		FROM tasks s
+		WHERE s.id = $2
+		RETURNING id
+	`, ownerID, taskID, next, seriesID, occurrence+1).Scan(&nextID)
+	if err != nil {
+		return fmt.Errorf("ошибка создания следующего повторения: %v", err)
+	}
+
+	if err = copyTaskMembers(tx, taskID, nextID); err != nil {
+		return fmt.Errorf("ошибка копирования участников: %v", err)
+	}
+
+	if err = copyTaskTags(tx, taskID, nextID); err != nil {
+		return fmt.Errorf("ошибка копирования тегов: %v", err)
+	}
+
+	// Следующее повторение создает система, а не пользователь
+	if err = recordTaskEvent(tx, nextID, nil, models.TaskEventCreated); err != nil {
+		return err
+	}
+
+	// Напоминания относительно срока переходят на новое повторение
+	_, err = tx.Exec(`
+		INSERT INTO reminders (task_id, offset_minutes)
+		SELECT $2, offset_minutes
+		FROM reminders
+		WHERE task_id = $1
+			AND offset_minutes IS NOT NULL
+	`, taskID, nextID)
+	if err != nil {
+		return fmt.Errorf("ошибка копирования напоминаний: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("невалидный refresh токен")
+	ErrRefreshTokenReused  = errors.New("refresh токен использован повторно, сессия отозвана")
+)
+
+// CreateSessionDataBase открывает сессию и первую цепочку refresh токенов в ней
+func CreateSessionDataBase(db *sql.DB, session *models.Session, tokenHash string, ttl time.Duration) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Заодно убираем истекшие сессии пользователя вместе с их токенами
+	if _, err = tx.Exec("DELETE FROM sessions WHERE user_id = $1 AND expires_at < now()", session.UserID); err != nil {
+		return err
+	}
+
+	session.ExpiresAt = time.Now().Add(ttl)
+	err = tx.QueryRow(`
+		INSERT INTO sessions (user_id, user_agent, ip, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, last_seen_at
+	`, session.UserID, session.UserAgent, session.IP, session.ExpiresAt).
+		Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, session.UserID, session.ID, tokenHash, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RotateRefreshTokenDataBase обменивает refresh токен на новый в той же сессии и возвращает её ID.
+// Повторное предъявление уже обмененного токена означает кражу: вся сессия отзывается.
+func RotateRefreshTokenDataBase(db *sql.DB, tokenHash string, newTokenHash string, ttl time.Duration) (user models.User, SessionID string, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return user, "", err
+	}
+	defer tx.Rollback()
+
+	var (
+		tokenID   string
+		expiresAt time.Time
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT rt.id, rt.family_id, rt.expires_at, rt.used_at, COALESCE(rt.revoked_at, s.revoked_at), u.id, u.login
+		FROM refresh_tokens rt
+		INNER JOIN sessions s ON s.id = rt.family_id
+		INNER JOIN users u ON u.id = rt.user_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt, s
+	`, tokenHash).Scan(&tokenID, &SessionID, &expiresAt, &usedAt, &revokedAt, &user.ID, &user.Login)
+	if err == sql.ErrNoRows {
+		return user, "", ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return user, "", err
+	}
+
+	if usedAt.Valid {
+		if err = revokeSession(tx, SessionID); err != nil {
+			return user, "", err
+		}
+		if err = tx.Commit(); err != nil {
+			return user, "", err
+		}
+		return user, SessionID, ErrRefreshTokenReused
+	}
+
+	if revokedAt.Valid || time.Now().After(expiresAt) {
+		return user, "", ErrRefreshTokenInvalid
+	}
+
+	if _, err = tx.Exec("UPDATE refresh_tokens SET used_at = now() WHERE id = $1", tokenID); err != nil {
+		return user, "", err
+	}
+
+	newExpiresAt := time.Now().Add(ttl)
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, user.ID, SessionID, newTokenHash, newExpiresAt)
+	if err != nil {
+		return user, "", err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE sessions
+		SET expires_at = $1,
+		    last_seen_at = now()
+		WHERE id = $2
+	`, newExpiresAt, SessionID)
+	if err != nil {
+		return user, "", err
+	}
+
+	return user, SessionID, tx.Commit()
+}
+
+// RevokeRefreshTokenDataBase отзывает сессию, к которой относится токен.
+// Неизвестный токен не считается ошибкой.
+func RevokeRefreshTokenDataBase(db *sql.DB, tokenHash string) error {
+	var SessionID string
+	err := db.QueryRow("SELECT family_id FROM refresh_tokens WHERE token_hash = $1", tokenHash).Scan(&SessionID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return revokeSession(db, SessionID)
+}
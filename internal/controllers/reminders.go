@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// reminderColumns колонки напоминания; срабатывание считается от срока задачи
+const reminderColumns = `
+		r.id,
+		r.task_id,
+		r.offset_minutes,
+		r.remind_at,
+		COALESCE(r.remind_at, t.due_date - make_interval(mins => r.offset_minutes)),
+		r.sent_at,
+		r.created_at`
+
+func scanReminder(row rowScanner, reminder *models.Reminder) error {
+	return row.Scan(
+		&reminder.ID,
+		&reminder.TaskID,
+		&reminder.OffsetMinutes,
+		&reminder.RemindAt,
+		&reminder.FireAt,
+		&reminder.SentAt,
+		&reminder.CreatedAt,
+	)
+}
+
+func GetRemindersDataBase(db *sql.DB, UserID *string, TaskID *string) (reminders []models.Reminder, err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return nil, err
+	}
+
+	reminders = []models.Reminder{}
+	query := `
+		SELECT ` + reminderColumns + `
+		FROM reminders r
+		INNER JOIN tasks t ON t.id = r.task_id
+		WHERE r.task_id = $1
+		ORDER BY 5 NULLS LAST, r.created_at
+	`
+
+	rows, err := db.Query(query, *TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reminder models.Reminder
+		if err = scanReminder(rows, &reminder); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return reminders, nil
+}
+
+func CreateReminderDataBase(db *sql.DB, UserID *string, TaskID *string, reminder *models.Reminder) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleEditor, false); err != nil {
+		return err
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO reminders (task_id, offset_minutes, remind_at)
+			VALUES ($1, $2, $3)
+			RETURNING *
+		)
+		SELECT ` + reminderColumns + `
+		FROM inserted r
+		INNER JOIN tasks t ON t.id = r.task_id
+	`
+
+	err = scanReminder(db.QueryRow(query, *TaskID, reminder.OffsetMinutes, reminder.RemindAt), reminder)
+	if err != nil {
+		return fmt.Errorf("ошибка создания напоминания: %v", err)
+	}
+
+	return nil
+}
+
+func SaveReminderDataBase(db *sql.DB, UserID *string, TaskID *string, ReminderID *string, reminder *models.Reminder) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleEditor, false); err != nil {
+		return err
+	}
+
+	// Измененное напоминание снова ждет отправки
+	query := `
+		WITH updated AS (
+			UPDATE reminders
+			SET offset_minutes = $1,
+			    remind_at = $2,
+			    sent_at = NULL
+			WHERE id = $3
+				AND task_id = $4
+			RETURNING *
+		)
+		SELECT ` + reminderColumns + `
+		FROM updated r
+		INNER JOIN tasks t ON t.id = r.task_id
+	`
+
+	err = scanReminder(db.QueryRow(query, reminder.OffsetMinutes, reminder.RemindAt, *ReminderID, *TaskID), reminder)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("напоминание не найдено")
+		}
+		return fmt.Errorf("ошибка изменения напоминания: %v", err)
+	}
+
+	return nil
+}
+
+func DeleteReminderDataBase(db *sql.DB, UserID *string, TaskID *string, ReminderID *string) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleEditor, false); err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`
+		DELETE FROM reminders
+		WHERE id = $1
+			AND task_id = $2
+	`, *ReminderID, *TaskID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("напоминание не найдено")
+	}
+
+	return nil
+}
+
+// addDefaultReminder добавляет напоминание по умолчанию задаче, у которой появился срок.
+// Вызывается, только если до изменения срока не было.
+func addDefaultReminder(tx *sql.Tx, taskID string) error {
+	_, err := tx.Exec(`
+		INSERT INTO reminders (task_id, offset_minutes)
+		SELECT t.id, $2
+		FROM tasks t
+		WHERE t.id = $1
+			AND t.due_date IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM reminders r WHERE r.task_id = t.id AND r.offset_minutes = $2
+			)
+	`, taskID, models.DefaultReminderOffset)
+	return err
+}
+
+// rearmReminders снова ставит в очередь относительные напоминания,
+// время срабатывания которых после смены срока оказалось в будущем
+func rearmReminders(tx *sql.Tx, taskID string) error {
+	_, err := tx.Exec(`
+		UPDATE reminders r
+		SET sent_at = NULL
+		FROM tasks t
+		WHERE t.id = r.task_id
+			AND r.task_id = $1
+			AND r.offset_minutes IS NOT NULL
+			AND r.sent_at IS NOT NULL
+			AND t.due_date - make_interval(mins => r.offset_minutes) > now()
+	`, taskID)
+	return err
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrSavedViewNotFound  = errors.New("представление не найдено")
+	ErrSavedViewNameTaken = errors.New("представление с таким названием уже существует")
+	ErrSavedViewsLimit    = fmt.Errorf("нельзя сохранить больше %d представлений", models.MaxSavedViews)
+)
+
+const savedViewColumns = `
+		v.id,
+		v.user_id,
+		v.name,
+		v.filters,
+		v.created_at,
+		v.updated_at`
+
+func scanSavedView(row rowScanner, view *models.SavedView) error {
+	var filters []byte
+
+	err := row.Scan(
+		&view.ID,
+		&view.UserID,
+		&view.Name,
+		&filters,
+		&view.CreatedAt,
+		&view.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	view.Filters = models.SavedViewFilters{}
+	if err = json.Unmarshal(filters, &view.Filters); err != nil {
+		return fmt.Errorf("ошибка чтения фильтра представления: %v", err)
+	}
+
+	return nil
+}
+
+func GetSavedViewsDataBase(db *sql.DB, UserID *string) (views []models.SavedView, err error) {
+	views = []models.SavedView{}
+
+	rows, err := db.Query(`
+		SELECT `+savedViewColumns+`
+		FROM saved_views v
+		WHERE v.user_id = $1
+		ORDER BY v.name, v.id
+	`, *UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var view models.SavedView
+		if err = scanSavedView(rows, &view); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		views = append(views, view)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return views, nil
+}
+
+func GetSavedViewDataBase(db *sql.DB, UserID *string, ViewID *string) (view models.SavedView, err error) {
+	err = scanSavedView(db.QueryRow(`
+		SELECT `+savedViewColumns+`
+		FROM saved_views v
+		WHERE v.user_id = $1
+			AND v.id = $2
+	`, *UserID, *ViewID), &view)
+	if err == sql.ErrNoRows {
+		return view, ErrSavedViewNotFound
+	}
+	if err != nil {
+		return view, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return view, nil
+}
+
+func CreateSavedViewDataBase(db *sql.DB, view *models.SavedView) (err error) {
+	filters, err := json.Marshal(view.Filters)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err = tx.QueryRow("SELECT count(*) FROM saved_views WHERE user_id = $1", view.UserID).Scan(&count); err != nil {
+		return err
+	}
+	if count >= models.MaxSavedViews {
+		return ErrSavedViewsLimit
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO saved_views (user_id, name, filters)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, view.UserID, view.Name, filters).Scan(&view.ID, &view.CreatedAt, &view.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrSavedViewNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка создания представления: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+func SaveSavedViewDataBase(db *sql.DB, UserID *string, ViewID *string, view *models.SavedView) (err error) {
+	filters, err := json.Marshal(view.Filters)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`
+		UPDATE saved_views
+		SET name = $1,
+		    filters = $2,
+		    updated_at = now()
+		WHERE user_id = $3
+			AND id = $4
+	`, view.Name, filters, *UserID, *ViewID)
+	if isUniqueViolation(err) {
+		return ErrSavedViewNameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSavedViewNotFound
+	}
+
+	return nil
+}
+
+func DeleteSavedViewDataBase(db *sql.DB, UserID *string, ViewID *string) (err error) {
+	result, err := db.Exec(`
+		DELETE FROM saved_views
+		WHERE user_id = $1
+			AND id = $2
+	`, *UserID, *ViewID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSavedViewNotFound
+	}
+
+	return nil
+}
+
+// isUniqueViolation сообщает, что запрос нарушил ограничение уникальности
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// searchHeadlineOptions подсветка совпадений для ts_headline
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// searchHTML экранирует текст до подсветки, чтобы в фрагментах был только тег <mark>
+func searchHTML(expr string) string {
+	return "replace(replace(replace(" + expr + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+}
+
+// SearchTasksDataBase ищет видимые пользователю задачи по названию, описанию и комментариям.
+// Запрос разбирается как в поисковиках: слова, "фразы", -исключения, or.
+func SearchTasksDataBase(db *sql.DB, UserID *string, request *models.SearchRequest) (results []models.SearchResult, nextCursor string, err error) {
+	results = []models.SearchResult{}
+
+	q := &taskQuery{}
+
+	// $1 - текущий пользователь, $2 - поисковый запрос
+	q.arg(*UserID)
+	query := q.arg(request.Query)
+	q.where("t.deleted = false")
+	q.where(taskReadable)
+	q.where("t.search_vector @@ websearch_to_tsquery('russian', " + query + ")")
+
+	rank := "ts_rank_cd(t.search_vector, websearch_to_tsquery('russian', " + query + "))"
+
+	// Keyset пагинация по релевантности
+	if request.Cursor != "" {
+		cursor, err := models.DecodeTaskCursor(request.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		q.where(fmt.Sprintf("(%s, t.id) < (%s::real, %s::uuid)", rank, q.arg(cursor.Key), q.arg(cursor.ID)))
+	}
+
+	sqlQuery := fmt.Sprintf(`
+        SELECT %s,
+    		(%s)::text,
+    		ts_headline('russian', %s, websearch_to_tsquery('russian', %s), 'HighlightAll=true'),
+    		ts_headline('russian', %s, websearch_to_tsquery('russian', %s), '%s')
+        FROM tasks t %s
+        WHERE %s
+        ORDER BY %s DESC, t.id DESC
+        LIMIT %s
+    `,
+		taskColumns,
+		rank,
+		searchHTML("t.title"), query,
+		searchHTML(`concat_ws(' … ', t.description, (
+    			SELECT string_agg(tc.body, ' … ' ORDER BY tc.created_at)
+    			FROM task_comments tc
+    			WHERE tc.task_id = t.id
+    		))`), query, searchHeadlineOptions,
+		taskJoins,
+		strings.Join(q.conditions, "\n        	AND "),
+		rank,
+		q.arg(request.Limit+1),
+	)
+
+	rows, err := db.Query(sqlQuery, q.args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	var rankKeys []string
+	for rows.Next() {
+		var (
+			result  models.SearchResult
+			rankKey string
+		)
+		err = scanTask(rows, &result.Task, &rankKey, &result.TitleHighlight, &result.Snippet)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+
+		if result.Rank, err = strconv.ParseFloat(rankKey, 64); err != nil {
+			return nil, "", fmt.Errorf("ошибка чтения релевантности: %v", err)
+		}
+		results = append(results, result)
+		rankKeys = append(rankKeys, rankKey)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	// Лишний результат означает, что есть следующая страница
+	if len(results) > request.Limit {
+		results = results[:request.Limit]
+		last := len(results) - 1
+		nextCursor = models.EncodeTaskCursor(models.TaskCursor{Key: rankKeys[last], ID: results[last].Task.ID})
+	}
+
+	return results, nextCursor, nil
+}
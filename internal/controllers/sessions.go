@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSessionNotFound = errors.New("сессия не найдена")
+	ErrSessionRevoked  = errors.New("сессия завершена")
+)
+
+// GetSessionsDataBase возвращает активные сессии пользователя, текущая отмечена Current
+func GetSessionsDataBase(db *sql.DB, UserID *string, CurrentSessionID string) (sessions []models.Session, err error) {
+	sessions = []models.Session{}
+
+	rows, err := db.Query(`
+		SELECT id, user_agent, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1
+			AND revoked_at IS NULL
+			AND expires_at > now()
+		ORDER BY last_seen_at DESC, id
+	`, *UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session models.Session
+		err = rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		session.Current = session.ID == CurrentSessionID
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSessionDataBase завершает сессию пользователя
+func RevokeSessionDataBase(db *sql.DB, UserID *string, SessionID *string) error {
+	var revoked bool
+	err := db.QueryRow(`
+		SELECT revoked_at IS NOT NULL
+		FROM sessions
+		WHERE user_id = $1
+			AND id = $2
+	`, *UserID, *SessionID).Scan(&revoked)
+	if err == sql.ErrNoRows || revoked {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return revokeSession(db, *SessionID)
+}
+
+// RevokeOtherSessionsDataBase завершает все сессии пользователя, кроме KeepSessionID
+func RevokeOtherSessionsDataBase(tx *sql.Tx, UserID string, KeepSessionID string) error {
+	_, err := tx.Exec(`
+		WITH revoked AS (
+			UPDATE sessions
+			SET revoked_at = now()
+			WHERE user_id = $1
+				AND id::text <> $2
+				AND revoked_at IS NULL
+			RETURNING id
+		)
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE family_id IN (SELECT id FROM revoked)
+			AND revoked_at IS NULL
+	`, UserID, KeepSessionID)
+	return err
+}
+
+// CheckSessionDataBase проверяет, что сессия access токена не завершена,
+// и отмечает активность не чаще раза в минуту
+func CheckSessionDataBase(db *sql.DB, UserID string, SessionID string) error {
+	var active, stale bool
+	err := db.QueryRow(`
+		SELECT revoked_at IS NULL AND expires_at > now(),
+		       last_seen_at < now() - interval '1 minute'
+		FROM sessions
+		WHERE user_id = $1
+			AND id::text = $2
+	`, UserID, SessionID).Scan(&active, &stale)
+	if err == sql.ErrNoRows || (err == nil && !active) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	if stale {
+		if _, err = db.Exec("UPDATE sessions SET last_seen_at = now() WHERE id = $1", SessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeSession завершает сессию и отзывает все её refresh токены
+func revokeSession(q querier, SessionID string) error {
+	_, err := q.Exec(`
+		UPDATE sessions
+		SET revoked_at = now()
+		WHERE id = $1
+			AND revoked_at IS NULL
+	`, SessionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE family_id = $1
+			AND revoked_at IS NULL
+	`, SessionID)
+	return err
+}
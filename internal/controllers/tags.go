@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// GetTagsDataBase возвращает теги пользователя с числом задач, где они используются
+func GetTagsDataBase(db *sql.DB, UserID *string) (tags []models.Tag, err error) {
+	tags = []models.Tag{}
+	query := `
+		SELECT tg.id, tg.name, count(t.id)
+		FROM tags tg
+		LEFT JOIN task_tags tt ON tt.tag_id = tg.id
+		LEFT JOIN tasks t ON t.id = tt.task_id AND t.deleted = false
+		WHERE tg.user_id = $1
+		GROUP BY tg.id, tg.name
+		ORDER BY tg.name
+	`
+
+	rows, err := db.Query(query, *UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag models.Tag
+		if err = rows.Scan(&tag.ID, &tag.Name, &tag.TasksCount); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return tags, nil
+}
+
+// setTaskTags заменяет теги задачи; теги хранятся в пространстве владельца задачи
+func setTaskTags(q querier, TaskID string, OwnerID string, tags []string) error {
+	_, err := q.Exec(`
+		INSERT INTO tags (user_id, name)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING
+	`, OwnerID, pq.Array(tags))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения тегов: %v", err)
+	}
+
+	_, err = q.Exec("DELETE FROM task_tags WHERE task_id = $1", TaskID)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения тегов: %v", err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO task_tags (task_id, tag_id)
+		SELECT $1, id
+		FROM tags
+		WHERE user_id = $2
+			AND name = ANY($3)
+	`, TaskID, OwnerID, pq.Array(tags))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения тегов: %v", err)
+	}
+
+	return nil
+}
+
+// copyTaskTags переносит теги задачи на новую задачу
+func copyTaskTags(q querier, fromTaskID string, toTaskID string) error {
+	_, err := q.Exec(`
+		INSERT INTO task_tags (task_id, tag_id)
+		SELECT $2, tag_id
+		FROM task_tags
+		WHERE task_id = $1
+	`, fromTaskID, toTaskID)
+	return err
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// taskHistoryFields поля задачи, изменения которых попадают в историю, в порядке записи
+var taskHistoryFields = []string{
+	"title",
+	"description",
+	"status",
+	"state",
+	"priority",
+	"due_date",
+	"recurrence",
+	"project_id",
+	"tags",
+}
+
+// taskSnapshot значения полей задачи для сравнения до и после изменения
+type taskSnapshot map[string]*string
+
+func loadTaskSnapshot(q querier, TaskID string) (taskSnapshot, error) {
+	values := make([]*string, len(taskHistoryFields))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	err := q.QueryRow(`
+		SELECT
+			t.title,
+			t.description,
+			t.status,
+			(SELECT ws.name FROM workflow_states ws WHERE ws.id = t.state_id),
+			t.priority,
+			t.due_date,
+			t.recurrence::text,
+			t.project_id::text,
+			NULLIF(array_to_string(ARRAY(
+				SELECT tg.name
+				FROM task_tags tt
+				INNER JOIN tags tg ON tg.id = tt.tag_id
+				WHERE tt.task_id = t.id
+				ORDER BY tg.name
+			), ', '), '')
+		FROM tasks t
+		WHERE t.id = $1
+	`, TaskID).Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := taskSnapshot{}
+	for i, field := range taskHistoryFields {
+		snapshot[field] = values[i]
+	}
+
+	return snapshot, nil
+}
+
+// recordTaskEvent пишет в историю событие без изменения полей
+func recordTaskEvent(q querier, TaskID string, UserID *string, action string) error {
+	_, err := q.Exec(`
+		INSERT INTO task_events (task_id, user_id, action)
+		VALUES ($1, $2, $3)
+	`, TaskID, UserID, action)
+	if err != nil {
+		return fmt.Errorf("ошибка записи истории задачи: %v", err)
+	}
+
+	return nil
+}
+
+// recordTaskChange пишет в историю изменение одного поля
+func recordTaskChange(q querier, TaskID string, UserID *string, field string, oldValue *string, newValue *string) error {
+	_, err := q.Exec(`
+		INSERT INTO task_events (task_id, user_id, action, field, old_value, new_value)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, TaskID, UserID, models.TaskEventUpdated, field, oldValue, newValue)
+	if err != nil {
+		return fmt.Errorf("ошибка записи истории задачи: %v", err)
+	}
+
+	return nil
+}
+
+// recordTaskChanges пишет в историю все поля, отличающиеся в снимках before и after
+func recordTaskChanges(q querier, TaskID string, UserID *string, before taskSnapshot, after taskSnapshot) error {
+	for _, field := range taskHistoryFields {
+		oldValue, newValue := before[field], after[field]
+		if oldValue == nil && newValue == nil {
+			continue
+		}
+		if oldValue != nil && newValue != nil && *oldValue == *newValue {
+			continue
+		}
+
+		if err := recordTaskChange(q, TaskID, UserID, field, oldValue, newValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTaskHistoryDataBase возвращает страницу истории задачи от новых событий к старым
+func GetTaskHistoryDataBase(db *sql.DB, UserID *string, TaskID *string, limit int, cursor string) (events []models.TaskEvent, nextCursor string, err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return nil, "", err
+	}
+
+	q := &taskQuery{}
+	q.where("e.task_id = " + q.arg(*TaskID))
+
+	if cursor != "" {
+		position, err := models.DecodeTaskCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		q.where(fmt.Sprintf("(e.created_at, e.id) < (%s::timestamptz, %s::uuid)", q.arg(position.Key), q.arg(position.ID)))
+	}
+
+	query := `
+		SELECT e.id, e.task_id, e.user_id, u.login, e.action, e.field, e.old_value, e.new_value, e.created_at,
+			e.created_at::text
+		FROM task_events e
+		LEFT JOIN users u ON u.id = e.user_id
+		WHERE ` + strings.Join(q.conditions, "\n			AND ") + `
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT ` + q.arg(limit+1)
+
+	rows, err := db.Query(query, q.args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	events = []models.TaskEvent{}
+	var keys []string
+	for rows.Next() {
+		var (
+			event models.TaskEvent
+			key   string
+		)
+		err = rows.Scan(
+			&event.ID,
+			&event.TaskID,
+			&event.UserID,
+			&event.Login,
+			&event.Action,
+			&event.Field,
+			&event.OldValue,
+			&event.NewValue,
+			&event.CreatedAt,
+			&key,
+		)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		events = append(events, event)
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	if len(events) > limit {
+		events = events[:limit]
+		last := len(events) - 1
+		nextCursor = models.EncodeTaskCursor(models.TaskCursor{Key: keys[last], ID: events[last].ID})
+	}
+
+	return events, nextCursor, nil
+}
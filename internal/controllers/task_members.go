@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTaskNotFound  = errors.New("задача не найдена")
+	ErrTaskForbidden = errors.New("недостаточно прав для операции с задачей")
+)
+
+// querier общий интерфейс *sql.DB и *sql.Tx
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// taskAccessJoin присоединяет участие текущего пользователя ($1) в задаче t
+const taskAccessJoin = `
+        LEFT JOIN task_members m ON m.task_id = t.id AND m.user_id = $1`
+
+// taskReadable условие видимости задачи текущему пользователю ($1)
+const taskReadable = `(t.user_id = $1 OR m.role IS NOT NULL)`
+
+// checkTaskAccess возвращает роль пользователя в задаче, если она не ниже required.
+// forUpdate блокирует строку задачи до конца транзакции.
+func checkTaskAccess(q querier, UserID string, TaskID string, required string, forUpdate bool) (role string, err error) {
+	query := `
+		SELECT CASE WHEN t.user_id = $1 THEN 'owner' ELSE COALESCE(m.role, '') END
+		FROM tasks t ` + taskAccessJoin + `
+		WHERE t.id = $2
+			AND t.deleted = false
+	`
+	if forUpdate {
+		query += " FOR UPDATE OF t"
+	}
+
+	err = q.QueryRow(query, UserID, TaskID).Scan(&role)
+	if err == sql.ErrNoRows || (err == nil && role == "") {
+		return "", ErrTaskNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !models.RoleAllows(role, required) {
+		return role, ErrTaskForbidden
+	}
+
+	return role, nil
+}
+
+func GetTaskMembersDataBase(db *sql.DB, UserID *string, TaskID *string) (members []models.TaskMember, err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleViewer, false); err != nil {
+		return nil, err
+	}
+
+	// Владелец выводится первым участником
+	members = []models.TaskMember{}
+	query := `
+		SELECT t.id, u.id, u.login, 'owner', t.created_at
+		FROM tasks t
+		INNER JOIN users u ON u.id = t.user_id
+		WHERE t.id = $1
+		UNION ALL
+		SELECT tm.task_id, u.id, u.login, tm.role, tm.created_at
+		FROM task_members tm
+		INNER JOIN users u ON u.id = tm.user_id
+		WHERE tm.task_id = $1
+	`
+
+	rows, err := db.Query(query, *TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.TaskMember
+		err = rows.Scan(&member.TaskID, &member.UserID, &member.Login, &member.Role, &member.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return members, nil
+}
+
+// AddTaskMemberDataBase приглашает пользователя по логину или меняет его роль
+func AddTaskMemberDataBase(db *sql.DB, UserID *string, TaskID *string, member *models.TaskMember) (err error) {
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, models.RoleOwner, false); err != nil {
+		return err
+	}
+
+	err = db.QueryRow("SELECT id FROM users WHERE login = $1", member.Login).Scan(&member.UserID)
+	if err == sql.ErrNoRows {
+		return errors.New("Пользователь не найден")
+	}
+	if err != nil {
+		return err
+	}
+
+	if member.UserID == *UserID {
+		return errors.New("владелец задачи уже имеет к ней доступ")
+	}
+
+	query := `
+		INSERT INTO task_members (task_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING task_id, created_at
+	`
+
+	err = db.QueryRow(query, *TaskID, member.UserID, member.Role).Scan(&member.TaskID, &member.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления участника: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteTaskMemberDataBase удаляет участника; владелец удаляет любого, участник - только себя
+func DeleteTaskMemberDataBase(db *sql.DB, UserID *string, TaskID *string, MemberID *string) (err error) {
+	required := models.RoleOwner
+	if *MemberID == *UserID {
+		required = models.RoleViewer
+	}
+
+	if _, err = checkTaskAccess(db, *UserID, *TaskID, required, false); err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`
+		DELETE FROM task_members
+		WHERE task_id = $1
+			AND user_id = $2
+	`, *TaskID, *MemberID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("участник не найден")
+	}
+
+	return nil
+}
+
+// copyTaskMembers переносит участников задачи на новую задачу
+func copyTaskMembers(q querier, fromTaskID string, toTaskID string) error {
+	_, err := q.Exec(`
+		INSERT INTO task_members (task_id, user_id, role)
+		SELECT $2, user_id, role
+		FROM task_members
+		WHERE task_id = $1
+	`, fromTaskID, toTaskID)
+	return err
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// taskSortColumn выражение сортировки и тип для приведения значения из курсора
+type taskSortColumn struct {
+	expr     string
+	castType string
+}
+
+var taskSortColumns = map[string]taskSortColumn{
+	"created_at": {expr: "t.created_at", castType: "timestamp"},
+	"updated_at": {expr: "t.updated_at", castType: "timestamp"},
+	"due_date":   {expr: "COALESCE(t.due_date, 'infinity'::timestamptz)", castType: "timestamptz"},
+	"priority":   {expr: "CASE t.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 ELSE 0 END", castType: "integer"},
+	"title":      {expr: "t.title", castType: "text"},
+	"position":   {expr: taskPosition, castType: "double precision"},
+}
+
+// taskColumns общий набор колонок задачи, читается через scanTask.
+// Параметр $1 запроса - ID текущего пользователя.
+const taskColumns = `
+            t.id,
+    		t.title,
+    		t.description,
+    		t.status,
+    		t.priority,
+    		t.due_date,
+    		t.created_at,
+    		t.updated_at,
+    		(t.due_date IS NOT NULL AND t.due_date < now() AND t.status <> 'completed'),
+    		EXISTS (
+    			SELECT 1
+    			FROM task_dependencies d
+    			INNER JOIN tasks b ON b.id = d.blocker_id
+    			WHERE d.task_id = t.id
+    				AND b.deleted = false
+    				AND b.status <> 'completed'
+    		),
+    		t.parent_id,
+    		t.project_id,
+    		ARRAY(
+    			SELECT tg.name
+    			FROM task_tags tt
+    			INNER JOIN tags tg ON tg.id = tt.tag_id
+    			WHERE tt.task_id = t.id
+    			ORDER BY tg.name
+    		),
+    		(SELECT ws.name FROM workflow_states ws WHERE ws.id = t.state_id),
+    		t.recurrence,
+    		t.series_id,
+    		t.occurrence,
+    		t.version,
+    		` + taskPosition + `,
+    		st.total,
+    		st.completed,
+    		CASE WHEN t.user_id = $1 THEN 'owner' ELSE m.role END`
+
+// taskPositionJoin присоединяет позицию задачи на доске текущего пользователя ($1)
+const taskPositionJoin = `
+        LEFT JOIN task_positions tp ON tp.task_id = t.id AND tp.user_id = $1`
+
+// taskPosition позиция задачи для текущего пользователя. Порядок на доске у каждого свой,
+// пока пользователь не двигал задачу, она стоит на позиции, назначенной при создании.
+const taskPosition = `COALESCE(tp.position, t.position)`
+
+// taskJoins подзапросы, необходимые для taskColumns
+const taskJoins = taskAccessJoin + taskPositionJoin + `
+        LEFT JOIN LATERAL (
+            SELECT
+                count(*) AS total,
+                count(*) FILTER (WHERE c.status = 'completed') AS completed
+            FROM tasks c
+            WHERE c.parent_id = t.id
+                AND c.deleted = false
+        ) st ON true`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask читает строку, выбранную с taskColumns, и дополнительные колонки после них
+func scanTask(row rowScanner, task *models.Task, extra ...interface{}) error {
+	var (
+		recurrence []byte
+		dueDate    sql.NullTime
+	)
+
+	dest := []interface{}{
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Priority,
+		&dueDate,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.Overdue,
+		&task.Blocked,
+		&task.ParentID,
+		&task.ProjectID,
+		pq.Array(&task.Tags),
+		&task.State,
+		&recurrence,
+		&task.SeriesID,
+		&task.Occurrence,
+		&task.Version,
+		&task.Position,
+		&task.SubtasksTotal,
+		&task.SubtasksCompleted,
+		&task.Role,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	// Срок отдаем в UTC, чтобы ответ не зависел от часового пояса сервера БД
+	task.DueDate = nil
+	if dueDate.Valid {
+		due := dueDate.Time.UTC().Format(time.RFC3339)
+		task.DueDate = &due
+	}
+
+	task.Recurrence = nil
+	if recurrence != nil {
+		task.Recurrence = &models.Recurrence{}
+		if err := json.Unmarshal(recurrence, task.Recurrence); err != nil {
+			return fmt.Errorf("ошибка чтения правила повторения: %v", err)
+		}
+	}
+
+	// Прогресс считаем только для задач с подзадачами
+	task.Progress = nil
+	if task.SubtasksTotal > 0 {
+		progress := task.SubtasksCompleted * 100 / task.SubtasksTotal
+		task.Progress = &progress
+	}
+
+	return nil
+}
+
+// taskQuery накапливает условия и параметры запроса
+type taskQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg добавляет параметр и возвращает его плейсхолдер
+func (q *taskQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *taskQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// buildTasksQuery собирает SQL запрос списка задач по фильтру.
+// Фильтр должен быть предварительно провалидирован.
+func buildTasksQuery(userID string, filter *models.TaskFilter) (string, []interface{}, error) {
+	q := &taskQuery{}
+
+	// $1 - текущий пользователь, на него ссылаются taskColumns и taskReadable
+	q.arg(userID)
+	q.where("t.deleted = false")
+	q.where(taskReadable)
+
+	if err := applyTaskFilter(q, filter); err != nil {
+		return "", nil, err
+	}
+
+	sort, ok := taskSortColumns[filter.Sort]
+	if !ok {
+		return "", nil, fmt.Errorf("неизвестное поле сортировки: %s", filter.Sort)
+	}
+
+	direction := "DESC"
+	comparison := "<"
+	if filter.Order == "asc" {
+		direction = "ASC"
+		comparison = ">"
+	}
+
+	// Keyset пагинация: продолжаем строго после последней выданной задачи
+	if filter.Cursor != "" {
+		cursor, err := models.DecodeTaskCursor(filter.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		q.where(fmt.Sprintf("(%s, t.id) %s (%s::%s, %s::uuid)",
+			sort.expr, comparison, q.arg(cursor.Key), sort.castType, q.arg(cursor.ID)))
+	}
+
+	// Запрашиваем на одну задачу больше, чтобы понять, есть ли следующая страница
+	query := fmt.Sprintf(`
+        SELECT %s,
+    		(%s)::text
+        FROM tasks t %s
+        WHERE %s
+        ORDER BY %s %s, t.id %s
+        LIMIT %s
+    `,
+		taskColumns,
+		sort.expr,
+		taskJoins,
+		strings.Join(q.conditions, "\n        	AND "),
+		sort.expr, direction, direction,
+		q.arg(filter.Limit+1),
+	)
+
+	return query, q.args, nil
+}
+
+// applyTaskFilter добавляет в запрос условия фильтра, кроме сортировки и курсора
+func applyTaskFilter(q *taskQuery, filter *models.TaskFilter) error {
+	// Задачи архивных проектов по умолчанию скрыты
+	switch {
+	case filter.Project == "none":
+		q.where("t.project_id IS NULL")
+	case filter.Project != "":
+		q.where("t.project_id = " + q.arg(filter.Project))
+	case !filter.IncludeArchived:
+		q.where("NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = t.project_id AND p.archived)")
+	}
+
+	if len(filter.Statuses) > 0 {
+		q.where("t.status = ANY(" + q.arg(pq.Array(filter.Statuses)) + ")")
+	}
+
+	if len(filter.Priorities) > 0 {
+		q.where("t.priority = ANY(" + q.arg(pq.Array(filter.Priorities)) + ")")
+	}
+
+	// Любой из тегов
+	if len(filter.TagsAny) > 0 {
+		q.where(`EXISTS (
+            SELECT 1
+            FROM task_tags tt
+            INNER JOIN tags tg ON tg.id = tt.tag_id
+            WHERE tt.task_id = t.id
+                AND tg.name = ANY(` + q.arg(pq.Array(filter.TagsAny)) + `))`)
+	}
+
+	// Все теги одновременно
+	if len(filter.TagsAll) > 0 {
+		q.where(`(
+            SELECT count(DISTINCT tg.name)
+            FROM task_tags tt
+            INNER JOIN tags tg ON tg.id = tt.tag_id
+            WHERE tt.task_id = t.id
+                AND tg.name = ANY(` + q.arg(pq.Array(filter.TagsAll)) + `)) = ` + q.arg(len(filter.TagsAll)))
+	}
+
+	// Границы периода считаем по дням в часовом поясе пользователя
+	if filter.DueFrom != nil && *filter.DueFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", *filter.DueFrom, filter.Location)
+		if err != nil {
+			return err
+		}
+		q.where("t.due_date >= " + q.arg(from))
+	}
+
+	if filter.DueTo != nil && *filter.DueTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", *filter.DueTo, filter.Location)
+		if err != nil {
+			return err
+		}
+		q.where("t.due_date < " + q.arg(to.AddDate(0, 0, 1)))
+	}
+
+	if filter.Overdue {
+		q.where("t.due_date < now()")
+		q.where("t.status <> 'completed'")
+	}
+
+	if filter.Query != "" {
+		q.where("t.title ILIKE '%' || " + q.arg(escapeLike(filter.Query)) + " || '%'")
+	}
+
+	return nil
+}
+
+// escapeLike экранирует спецсимволы шаблона ILIKE
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
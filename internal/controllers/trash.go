@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTaskNotInTrash    = errors.New("задача не найдена в корзине")
+	ErrParentTaskInTrash = errors.New("родительская задача в корзине, сначала восстановите её")
+)
+
+// trashRoot условие для задач, удаленных отдельно от родительской.
+// Подзадачи, удаленные вместе с родительской, восстанавливаются и удаляются вместе с ней.
+const trashRoot = `NOT EXISTS (
+            SELECT 1
+            FROM tasks p
+            WHERE p.id = t.parent_id
+                AND p.deleted = true
+                AND p.deleted_at IS NOT DISTINCT FROM t.deleted_at
+        )`
+
+// GetTrashDataBase возвращает задачи пользователя в корзине, последние удаленные первыми
+func GetTrashDataBase(db *sql.DB, UserID *string) (tasks []models.Task, err error) {
+	tasks = []models.Task{}
+	query := `
+        SELECT ` + taskColumns + `,
+    		t.deleted_at
+        FROM tasks t ` + taskJoins + `
+        WHERE t.deleted = true
+        	AND t.user_id = $1
+        	AND ` + trashRoot + `
+        ORDER BY t.deleted_at DESC, t.id
+    `
+
+	rows, err := db.Query(query, *UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task models.Task
+		if err = scanTask(rows, &task, &task.DeletedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return tasks, nil
+}
+
+// checkTrashedTask блокирует удаленную задачу владельца до конца транзакции.
+// У задач, удаленных до появления deleted_at, времени удаления нет.
+func checkTrashedTask(tx *sql.Tx, UserID string, TaskID string) (deletedAt sql.NullTime, parentInTrash bool, err error) {
+	err = tx.QueryRow(`
+		SELECT t.deleted_at, COALESCE(p.deleted, false)
+		FROM tasks t
+		LEFT JOIN tasks p ON p.id = t.parent_id
+		WHERE t.id = $1
+			AND t.user_id = $2
+			AND t.deleted = true
+		FOR UPDATE OF t
+	`, TaskID, UserID).Scan(&deletedAt, &parentInTrash)
+	if err == sql.ErrNoRows {
+		return deletedAt, false, ErrTaskNotInTrash
+	}
+
+	return deletedAt, parentInTrash, err
+}
+
+// RestoreTaskDataBase восстанавливает задачу из корзины вместе с подзадачами, удаленными вместе с ней
+func RestoreTaskDataBase(db *sql.DB, UserID *string, TaskID *string) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deletedAt, parentInTrash, err := checkTrashedTask(tx, *UserID, *TaskID)
+	if err != nil {
+		return err
+	}
+	if parentInTrash {
+		return ErrParentTaskInTrash
+	}
+
+	_, err = tx.Exec(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM tasks WHERE id = $1
+			UNION ALL
+			SELECT t.id FROM tasks t
+			INNER JOIN subtree s ON t.parent_id = s.id
+			WHERE t.deleted = true
+				AND t.deleted_at IS NOT DISTINCT FROM $3
+		), restored AS (
+			UPDATE tasks
+			SET deleted = false,
+			    deleted_at = NULL,
+			    version = version + 1
+			WHERE id IN (SELECT id FROM subtree)
+			RETURNING id
+		)
+		INSERT INTO task_events (task_id, user_id, action)
+		SELECT id, $2::uuid, 'restored'
+		FROM restored
+	`, *TaskID, *UserID, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeTaskDataBase окончательно удаляет задачу из корзины; подзадачи удаляются каскадно
+func PurgeTaskDataBase(db *sql.DB, UserID *string, TaskID *string) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, _, err = checkTrashedTask(tx, *UserID, *TaskID); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM tasks WHERE id = $1", *TaskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EmptyTrashDataBase окончательно удаляет все задачи пользователя из корзины
+func EmptyTrashDataBase(db *sql.DB, UserID *string) (purged int64, err error) {
+	result, err := db.Exec("DELETE FROM tasks WHERE user_id = $1 AND deleted = true", *UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
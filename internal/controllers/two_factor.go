@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTwoFactorEnabled      = errors.New("двухфакторная аутентификация уже включена")
+	ErrTwoFactorNotEnabled   = errors.New("двухфакторная аутентификация не включена")
+	ErrTwoFactorNotEnrolled  = errors.New("подключение двухфакторной аутентификации не начато")
+	ErrTwoFactorCodeInvalid  = errors.New("неверный код подтверждения")
+	ErrLoginChallengeInvalid = errors.New("недействительный или истекший токен входа")
+)
+
+// Число попыток ввести код по одному токену второго шага входа
+const MaxLoginChallengeAttempts = 5
+
+// userTOTP секрет TOTP пользователя, заблокированный в транзакции
+type userTOTP struct {
+	secret   string
+	enabled  bool
+	lastStep int64
+}
+
+// EnrollTwoFactorDataBase сохраняет новый неподтвержденный секрет.
+// Повторное подключение до подтверждения заменяет секрет.
+func EnrollTwoFactorDataBase(db *sql.DB, UserID *string, secret string) error {
+	result, err := db.Exec(`
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+		    last_used_step = 0,
+		    created_at = now()
+		WHERE user_totp.enabled_at IS NULL
+	`, *UserID, secret)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// ConfirmTwoFactorDataBase включает 2FA после проверки первого кода и сохраняет хеши кодов восстановления
+func ConfirmTwoFactorDataBase(db *sql.DB, UserID *string, code string, recoveryHashes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	totp, err := lockUserTOTP(tx, *UserID)
+	if err == sql.ErrNoRows {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if totp.enabled {
+		return ErrTwoFactorEnabled
+	}
+
+	step, ok := services.ValidateTOTP(totp.secret, code, time.Now(), totp.lastStep)
+	if !ok {
+		return ErrTwoFactorCodeInvalid
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_totp
+		SET enabled_at = now(),
+		    last_used_step = $2
+		WHERE user_id = $1
+	`, *UserID, step)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if err = replaceRecoveryCodes(tx, *UserID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTwoFactorStatusDataBase состояние 2FA и число неиспользованных кодов восстановления
+func GetTwoFactorStatusDataBase(db *sql.DB, UserID *string) (models.TwoFactorStatus, error) {
+	var status models.TwoFactorStatus
+
+	err := db.QueryRow(`
+		SELECT
+			COALESCE(bool_or(t.enabled_at IS NOT NULL), false),
+			COALESCE(bool_or(t.enabled_at IS NULL), false),
+			(SELECT COUNT(*) FROM recovery_codes rc WHERE rc.user_id = $1 AND rc.used_at IS NULL)
+		FROM user_totp t
+		WHERE t.user_id = $1
+	`, *UserID).Scan(&status.Enabled, &status.Pending, &status.RecoveryCodesLeft)
+	if err != nil {
+		return status, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if !status.Enabled {
+		status.RecoveryCodesLeft = 0
+	}
+
+	return status, nil
+}
+
+// TwoFactorEnabledDataBase требуется ли пользователю второй шаг входа
+func TwoFactorEnabledDataBase(db *sql.DB, UserID string) (bool, error) {
+	var enabled bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM user_totp WHERE user_id = $1 AND enabled_at IS NOT NULL)
+	`, UserID).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return enabled, nil
+}
+
+// DisableTwoFactorDataBase отключает 2FA по коду TOTP или коду восстановления
+func DisableTwoFactorDataBase(db *sql.DB, UserID *string, code string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err = checkTwoFactorCode(tx, *UserID, code); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM user_totp WHERE user_id = $1", *UserID); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if _, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", *UserID); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if _, err = tx.Exec("DELETE FROM login_challenges WHERE user_id = $1", *UserID); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodesDataBase заменяет коды восстановления новыми; старые перестают действовать
+func RegenerateRecoveryCodesDataBase(db *sql.DB, UserID *string, code string, recoveryHashes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err = checkTwoFactorCode(tx, *UserID, code); err != nil {
+		return err
+	}
+
+	if err = replaceRecoveryCodes(tx, *UserID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateLoginChallengeDataBase сохраняет токен второго шага входа
+func CreateLoginChallengeDataBase(db *sql.DB, UserID string, tokenHash string, ttl time.Duration) error {
+	// Заодно убираем отработавшие токены пользователя
+	_, err := db.Exec(`
+		DELETE FROM login_challenges
+		WHERE user_id = $1
+			AND (expires_at < now() OR used_at IS NOT NULL)
+	`, UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO login_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, UserID, tokenHash, time.Now().Add(ttl))
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return nil
+}
+
+// GetLoginChallengeLoginDataBase логин пользователя действующего токена второго шага входа
+func GetLoginChallengeLoginDataBase(db *sql.DB, tokenHash string) (login string, err error) {
+	err = db.QueryRow(`
+		SELECT u.login
+		FROM login_challenges c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.token_hash = $1
+			AND c.used_at IS NULL
+			AND c.expires_at > now()
+			AND c.attempts < $2
+	`, tokenHash, MaxLoginChallengeAttempts).Scan(&login)
+	if err == sql.ErrNoRows {
+		return "", ErrLoginChallengeInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return login, nil
+}
+
+// CompleteLoginChallengeDataBase обменивает токен второго шага и код на пользователя.
+// Токен одноразовый; после MaxLoginChallengeAttempts неверных кодов он сгорает.
+func CompleteLoginChallengeDataBase(db *sql.DB, tokenHash string, code string) (user models.User, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return user, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var challengeID string
+	err = tx.QueryRow(`
+		SELECT c.id, u.id, u.login
+		FROM login_challenges c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.token_hash = $1
+			AND c.used_at IS NULL
+			AND c.expires_at > now()
+			AND c.attempts < $2
+		FOR UPDATE OF c
+	`, tokenHash, MaxLoginChallengeAttempts).Scan(&challengeID, &user.ID, &user.Login)
+	if err == sql.ErrNoRows {
+		return user, ErrLoginChallengeInvalid
+	}
+	if err != nil {
+		return user, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	err = checkTwoFactorCode(tx, user.ID, code)
+	if errors.Is(err, ErrTwoFactorNotEnabled) {
+		return user, ErrLoginChallengeInvalid
+	}
+	if errors.Is(err, ErrTwoFactorCodeInvalid) {
+		// Неверная попытка засчитывается, последняя сжигает токен
+		_, err = tx.Exec(`
+			UPDATE login_challenges
+			SET attempts = attempts + 1,
+			    used_at = CASE WHEN attempts + 1 >= $2 THEN now() END
+			WHERE id = $1
+		`, challengeID, MaxLoginChallengeAttempts)
+		if err != nil {
+			return user, fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+		if err = tx.Commit(); err != nil {
+			return user, err
+		}
+		return user, ErrTwoFactorCodeInvalid
+	}
+	if err != nil {
+		return user, err
+	}
+
+	if _, err = tx.Exec("UPDATE login_challenges SET used_at = now() WHERE id = $1", challengeID); err != nil {
+		return user, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return user, tx.Commit()
+}
+
+// checkTwoFactorCode проверяет код TOTP или погашает код восстановления включенной 2FA
+func checkTwoFactorCode(tx *sql.Tx, UserID string, code string) error {
+	totp, err := lockUserTOTP(tx, UserID)
+	if err == sql.ErrNoRows || (err == nil && !totp.enabled) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	if services.IsTOTPCode(code) {
+		step, ok := services.ValidateTOTP(totp.secret, code, time.Now(), totp.lastStep)
+		if !ok {
+			return ErrTwoFactorCodeInvalid
+		}
+
+		if _, err = tx.Exec("UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1", UserID, step); err != nil {
+			return fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+		return nil
+	}
+
+	result, err := tx.Exec(`
+		UPDATE recovery_codes
+		SET used_at = now()
+		WHERE user_id = $1
+			AND code_hash = $2
+			AND used_at IS NULL
+	`, UserID, services.HashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTwoFactorCodeInvalid
+	}
+
+	return nil
+}
+
+// lockUserTOTP читает секрет пользователя с блокировкой строки
+func lockUserTOTP(tx *sql.Tx, UserID string) (totp userTOTP, err error) {
+	err = tx.QueryRow(`
+		SELECT secret, enabled_at IS NOT NULL, last_used_step
+		FROM user_totp
+		WHERE user_id = $1
+		FOR UPDATE
+	`, UserID).Scan(&totp.secret, &totp.enabled, &totp.lastStep)
+	if err != nil && err != sql.ErrNoRows {
+		return totp, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return totp, err
+}
+
+// replaceRecoveryCodes заменяет все коды восстановления пользователя
+func replaceRecoveryCodes(tx *sql.Tx, UserID string, recoveryHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", UserID); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	for _, hash := range recoveryHashes {
+		_, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", UserID, hash)
+		if err != nil {
+			return fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetUserLocation возвращает часовой пояс пользователя
+func GetUserLocation(db *sql.DB, UserID *string) (*time.Location, error) {
+	var timezone string
+
+	err := db.QueryRow("SELECT timezone FROM users WHERE id = $1", *UserID).Scan(&timezone)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("Пользователь не найден")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+
+	return loc, nil
+}
+
+// SaveUserTimezoneDataBase сохраняет часовой пояс пользователя в формате IANA
+func SaveUserTimezoneDataBase(db *sql.DB, UserID *string, timezone string) error {
+	if timezone == "" {
+		return errors.New("часовой пояс не может быть пустым")
+	}
+
+	// "Local" - часовой пояс сервера, для пользователя он не имеет смысла
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return errors.New("неизвестный часовой пояс")
+	}
+
+	result, err := db.Exec("UPDATE users SET timezone = $1 WHERE id = $2", timezone, *UserID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("Пользователь не найден")
+	}
+
+	return nil
+}
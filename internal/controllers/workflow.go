@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"TaskManager/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWorkflowStateNotFound = errors.New("состояние не найдено в процессе владельца задачи")
+	ErrTransitionNotAllowed  = errors.New("переход в это состояние не разрешен")
+)
+
+// workflowStateFor подзапрос состояния по умолчанию для категории category
+// в процессе владельца задачи; для active это начальное состояние.
+// tasksAlias - имя таблицы задач в запросе.
+func workflowStateFor(tasksAlias string, category string) string {
+	return `(
+		SELECT ws.id
+		FROM workflow_states ws
+		WHERE ws.user_id = ` + tasksAlias + `.user_id
+			AND ws.category = ` + category + `
+		ORDER BY ws.is_initial DESC, ws.position
+		LIMIT 1
+	)`
+}
+
+func GetWorkflowDataBase(db *sql.DB, UserID *string) (workflow models.Workflow, err error) {
+	workflow = models.Workflow{
+		States:      []models.WorkflowState{},
+		Transitions: []models.WorkflowTransition{},
+	}
+
+	rows, err := db.Query(`
+		SELECT id, name, category, is_initial, position
+		FROM workflow_states
+		WHERE user_id = $1
+		ORDER BY position
+	`, *UserID)
+	if err != nil {
+		return workflow, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var state models.WorkflowState
+		if err = rows.Scan(&state.ID, &state.Name, &state.Category, &state.Initial, &state.Position); err != nil {
+			return workflow, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		workflow.States = append(workflow.States, state)
+	}
+	if err = rows.Err(); err != nil {
+		return workflow, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	transitions, err := db.Query(`
+		SELECT f.name, t.name
+		FROM workflow_transitions wt
+		INNER JOIN workflow_states f ON f.id = wt.from_state_id
+		INNER JOIN workflow_states t ON t.id = wt.to_state_id
+		WHERE f.user_id = $1
+		ORDER BY f.position, t.position
+	`, *UserID)
+	if err != nil {
+		return workflow, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer transitions.Close()
+
+	for transitions.Next() {
+		var transition models.WorkflowTransition
+		if err = transitions.Scan(&transition.From, &transition.To); err != nil {
+			return workflow, fmt.Errorf("ошибка сканирования строки: %v", err)
+		}
+		workflow.Transitions = append(workflow.Transitions, transition)
+	}
+	if err = transitions.Err(); err != nil {
+		return workflow, fmt.Errorf("ошибка чтения строк: %v", err)
+	}
+
+	return workflow, nil
+}
+
+// SaveWorkflowDataBase заменяет процесс пользователя целиком.
+// Состояния сопоставляются по имени; задачи удаленных состояний и задачи,
+// чей статус не совпадает с категорией состояния, получают состояние по умолчанию.
+func SaveWorkflowDataBase(db *sql.DB, UserID *string, workflow *models.Workflow) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Начальное состояние уникально, поэтому сначала снимаем флаг со всех
+	if _, err = tx.Exec("UPDATE workflow_states SET is_initial = false WHERE user_id = $1", *UserID); err != nil {
+		return err
+	}
+
+	names := make([]string, len(workflow.States))
+	for i := range workflow.States {
+		state := &workflow.States[i]
+		names[i] = state.Name
+
+		err = tx.QueryRow(`
+			INSERT INTO workflow_states (user_id, name, category, is_initial, position)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, name) DO UPDATE
+			SET category = EXCLUDED.category,
+			    is_initial = EXCLUDED.is_initial,
+			    position = EXCLUDED.position
+			RETURNING id
+		`, *UserID, state.Name, state.Category, state.Initial, state.Position).Scan(&state.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM workflow_states
+		WHERE user_id = $1
+			AND NOT name = ANY($2)
+	`, *UserID, pq.Array(names))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM workflow_transitions
+		WHERE from_state_id IN (SELECT id FROM workflow_states WHERE user_id = $1)
+	`, *UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, transition := range workflow.Transitions {
+		_, err = tx.Exec(`
+			INSERT INTO workflow_transitions (from_state_id, to_state_id)
+			SELECT f.id, t.id
+			FROM workflow_states f, workflow_states t
+			WHERE f.user_id = $1 AND f.name = $2
+				AND t.user_id = $1 AND t.name = $3
+			ON CONFLICT DO NOTHING
+		`, *UserID, transition.From, transition.To)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = syncTaskStates(tx, *UserID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteWorkflowDataBase удаляет процесс пользователя, задачи возвращаются к статусам active/completed
+func DeleteWorkflowDataBase(db *sql.DB, UserID *string) error {
+	// Переходы и ссылки задач на состояния удаляются внешними ключами
+	_, err := db.Exec("DELETE FROM workflow_states WHERE user_id = $1", *UserID)
+	return err
+}
+
+// syncTaskStates назначает состояние по умолчанию задачам без подходящего состояния
+func syncTaskStates(tx *sql.Tx, UserID string) error {
+	_, err := tx.Exec(`
+		UPDATE tasks
+		SET state_id = `+workflowStateFor("tasks", "tasks.status")+`
+		WHERE user_id = $1
+			AND NOT EXISTS (
+				SELECT 1
+				FROM workflow_states s
+				WHERE s.id = tasks.state_id
+					AND s.category = tasks.status
+			)
+	`, UserID)
+	return err
+}
+
+// TransitionTaskDataBase переводит задачу в состояние stateName процесса её владельца.
+// Переход должен быть разрешен из текущего состояния задачи. Заблокированную задачу
+// можно перевести в завершающее состояние только с force.
+func TransitionTaskDataBase(db *sql.DB, UserID *string, TaskID *string, stateName string, force bool) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Менять состояние может владелец или редактор
+	if _, err = checkTaskAccess(tx, *UserID, *TaskID, models.RoleEditor, true); err != nil {
+		return err
+	}
+
+	if err = transitionTask(tx, UserID, *TaskID, stateName, force); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// transitionTask переводит заблокированную задачу в состояние stateName процесса её владельца
+func transitionTask(tx *sql.Tx, UserID *string, TaskID string, stateName string, force bool) (err error) {
+	var (
+		oldStatus      string
+		currentStateID sql.NullString
+		targetStateID  string
+		targetCategory string
+	)
+	err = tx.QueryRow(`
+		SELECT t.status, t.state_id, ws.id, ws.category
+		FROM tasks t
+		INNER JOIN workflow_states ws ON ws.user_id = t.user_id AND ws.name = $2
+		WHERE t.id = $1
+	`, TaskID, stateName).Scan(&oldStatus, &currentStateID, &targetStateID, &targetCategory)
+	if err == sql.ErrNoRows {
+		return ErrWorkflowStateNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if currentStateID.Valid && currentStateID.String == targetStateID {
+		return nil
+	}
+
+	// Задача без состояния может перейти в любое состояние
+	if currentStateID.Valid {
+		var allowed bool
+		err = tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1
+				FROM workflow_transitions
+				WHERE from_state_id = $1
+					AND to_state_id = $2
+			)
+		`, currentStateID.String, targetStateID).Scan(&allowed)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrTransitionNotAllowed
+		}
+	}
+
+	if targetCategory == models.StateCategoryCompleted && oldStatus != models.StateCategoryCompleted && !force {
+		if err = checkTaskBlockers(tx, TaskID); err != nil {
+			return err
+		}
+	}
+
+	before, err := loadTaskSnapshot(tx, TaskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE tasks
+		SET state_id = $1,
+		    status = $2,
+		    updated_at = now(),
+		    version = version + 1
+		WHERE id = $3
+	`, targetStateID, targetCategory, TaskID)
+	if err != nil {
+		return err
+	}
+
+	after, err := loadTaskSnapshot(tx, TaskID)
+	if err != nil {
+		return err
+	}
+	if err = recordTaskChanges(tx, TaskID, UserID, before, after); err != nil {
+		return err
+	}
+
+	// Завершенная повторяющаяся задача порождает следующее повторение
+	if targetCategory == models.StateCategoryCompleted && oldStatus != models.StateCategoryCompleted {
+		if err = createNextOccurrence(tx, TaskID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
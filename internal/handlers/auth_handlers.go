@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
 	"encoding/json"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Обработчик главной страницы
@@ -77,17 +83,85 @@ func (a *App) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Защита от перебора: пока действует задержка, блокировка или идет другая попытка, пароль не проверяем
+	login, ip := truncateString(req.Login, 255), clientIP(r)
+	reservation, ok := a.reserveLoginAttempt(w, login, ip)
+	if !ok {
+		return
+	}
+
 	// Аутентифицируем пользователя
 	authUser, err := controllers.Authenticate(a.db, req.Login, req.Password)
 	if err != nil {
+		if err := controllers.RecordLoginFailureDataBase(a.db, a.loginPolicy, login, ip); err != nil {
+			log.Printf("Ошибка учета неудачного входа: %v", err)
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный логин или пароль"})
 		return
 	}
 
-	// Генерируем JWT токен
-	token, err := a.jwtService.GenerateToken(authUser)
+	// При включенной 2FA вместо токенов выдаем токен второго шага входа
+	twoFactor, err := controllers.TwoFactorEnabledDataBase(a.db, authUser.ID)
+	if err != nil {
+		a.releaseLoginAttempt(login, ip, reservation)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка проверки двухфакторной аутентификации"})
+		return
+	}
+	if twoFactor {
+		// Счетчик логина сбрасывается только после второго шага
+		a.releaseLoginAttempt(login, ip, reservation)
+		a.writeLoginChallenge(w, authUser)
+		return
+	}
+
+	if err = controllers.ResetLoginThrottleDataBase(a.db, login, ip, reservation); err != nil {
+		log.Printf("Ошибка сброса счетчика входа: %v", err)
+	}
+
+	a.startSession(w, r, authUser)
+}
+
+// reserveLoginAttempt занимает логин и адрес под попытку входа; при отказе пишет ответ
+func (a *App) reserveLoginAttempt(w http.ResponseWriter, login string, ip string) (models.LoginReservation, bool) {
+	reservation, retryAfter, err := controllers.ReserveLoginAttemptDataBase(a.db, a.loginPolicy, login, ip)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка проверки попыток входа"})
+		return nil, false
+	}
+	if retryAfter > 0 {
+		writeTooManyLoginAttempts(w, retryAfter)
+		return nil, false
+	}
+
+	return reservation, true
+}
+
+// releaseLoginAttempt снимает бронь логина и адреса, если попытка завершилась не неудачей
+func (a *App) releaseLoginAttempt(login string, ip string, reservation models.LoginReservation) {
+	if err := controllers.ReleaseLoginAttemptDataBase(a.db, login, ip, reservation); err != nil {
+		log.Printf("Ошибка освобождения попытки входа: %v", err)
+	}
+}
+
+// startSession открывает новую сессию с первым refresh токеном и отдает токены
+func (a *App) startSession(w http.ResponseWriter, r *http.Request, authUser *models.User) {
+	session := models.Session{
+		UserID:    authUser.ID,
+		UserAgent: truncateString(r.UserAgent(), 512),
+		IP:        clientIP(r),
+	}
+
+	refreshToken, refreshHash, err := services.GenerateRefreshToken()
+	if err == nil {
+		err = controllers.CreateSessionDataBase(a.db, &session, refreshHash, a.jwtService.RefreshTTL())
+	}
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -95,15 +169,33 @@ func (a *App) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Формируем ответ
-	response := struct {
-		//ID    string `json:"id"`
-		Token string `json:"token"`
-	}{
-		//ID:    authUser.ID,
-		Token: token,
+	a.writeTokens(w, authUser, session.ID, refreshToken)
+}
+
+// Обработчик набора открытых ключей (JWKS) для проверки токенов
+func (a *App) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
 	}
 
+	// Ключи меняются редко; короткий кэш не мешает ротации
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(a.jwtService.JWKS())
+}
+
+// writeTooManyLoginAttempts ответ 429 с временем ожидания в секундах
+func writeTooManyLoginAttempts(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       "Слишком много попыток входа, повторите позже",
+		"retry_after": seconds,
+	})
 }
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик доски задач: GET /api/board
+func (a *App) BoardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
+	if err == nil {
+		filter.Location, err = controllers.GetUserLocation(a.db, &userClaims.UserID)
+	}
+	if err == nil {
+		err = filter.Validate()
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	columns, err := controllers.GetBoardDataBase(a.db, &userClaims.UserID, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при получении доски"})
+		return
+	}
+
+	response := struct {
+		Columns []models.BoardColumn `json:"columns"`
+	}{
+		Columns: columns,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Обработчик перемещения задачи по доске: POST /api/tasks/{id}/move
+func (a *App) MoveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	var move models.TaskMove
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный формат JSON"})
+		return
+	}
+
+	taskID := parts[0]
+	if err := move.Validate(taskID); err != nil {
+		writeTaskInputError(w, err)
+		return
+	}
+
+	// force=true завершает задачу с незавершенными блокирующими задачами
+	force := r.URL.Query().Get("force") == "true"
+
+	if err := controllers.MoveTaskDataBase(a.db, &userClaims.UserID, &taskID, &move, force); err != nil {
+		w.WriteHeader(boardErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	task, err := controllers.GetTaskDataBase(a.db, &userClaims.UserID, &taskID)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(task.Version))
+	json.NewEncoder(w).Encode(task)
+}
+
+func boardErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrBoardNeighborNotFound),
+		errors.Is(err, controllers.ErrBoardNeighborsOrder):
+		return http.StatusConflict
+	case errors.Is(err, controllers.ErrBoardColumnNotFound):
+		return http.StatusUnprocessableEntity
+	default:
+		return workflowErrorStatus(err)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Обработчик массовых операций над задачами: POST /api/tasks/bulk
+func (a *App) BulkTasksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	request := models.BulkTaskRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	// Срок приводим к часовому поясу пользователя, пустой срок снимается
+	if request.Action == models.BulkActionSetDueDate {
+		loc, err := controllers.GetUserLocation(a.db, &userClaims.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if request.DueDate, err = models.NormalizeDueDate(request.DueDate, loc); err != nil {
+			writeTaskInputError(w, err)
+			return
+		}
+	}
+
+	if err := request.Validate(); err != nil {
+		writeTaskInputError(w, err)
+		return
+	}
+
+	// force=true завершает и задачи с незавершенными блокирующими задачами
+	force := r.URL.Query().Get("force") == "true"
+
+	results, err := controllers.BulkTasksDataBase(a.db, &userClaims.UserID, &request, force)
+	if errors.Is(err, controllers.ErrProjectNotFound) {
+		w.WriteHeader(projectErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка массовой операции: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при выполнении массовой операции"})
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.OK {
+			failed++
+		}
+	}
+
+	response := struct {
+		Action    string                  `json:"action"`
+		Succeeded int                     `json:"succeeded"`
+		Failed    int                     `json:"failed"`
+		Results   []models.BulkTaskResult `json:"results"`
+	}{
+		Action:    request.Action,
+		Succeeded: len(results) - failed,
+		Failed:    failed,
+		Results:   results,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Обработчик комментариев задачи: /api/tasks/{id}/comments[/{commentId}]
+func (a *App) TaskCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) < 2 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	taskID := parts[0]
+	commentID := ""
+	if len(parts) > 2 {
+		commentID = parts[2]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && commentID == "":
+		a.getComments(w, r, userClaims, taskID)
+	case r.Method == http.MethodPost && commentID == "":
+		a.createComment(w, r, userClaims, taskID)
+	case r.Method == http.MethodPut && commentID != "":
+		a.saveComment(w, r, userClaims, taskID, commentID)
+	case r.Method == http.MethodDelete && commentID != "":
+		a.deleteComment(w, userClaims, taskID, commentID)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func (a *App) getComments(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string) {
+	limit, err := parsePageLimit(r, models.DefaultCommentsLimit, models.MaxCommentsLimit)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	comments, nextCursor, err := controllers.GetCommentsDataBase(a.db, &userClaims.UserID, &taskID, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		w.WriteHeader(commentErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := struct {
+		Comments   []models.Comment `json:"comments"`
+		NextCursor string           `json:"next_cursor,omitempty"`
+	}{
+		Comments:   comments,
+		NextCursor: nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *App) createComment(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string) {
+	comment, err := decodeComment(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := controllers.CreateCommentDataBase(a.db, &userClaims.UserID, &taskID, comment); err != nil {
+		w.WriteHeader(commentErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Комментарий уже сохранен, уведомления отправляем в фоне
+	go a.notifyComment(*comment)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (a *App) saveComment(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string, commentID string) {
+	comment, err := decodeComment(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := controllers.SaveCommentDataBase(a.db, &userClaims.UserID, &taskID, &commentID, comment); err != nil {
+		w.WriteHeader(commentErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (a *App) deleteComment(w http.ResponseWriter, userClaims *services.Claims, taskID string, commentID string) {
+	if err := controllers.DeleteCommentDataBase(a.db, &userClaims.UserID, &taskID, &commentID); err != nil {
+		w.WriteHeader(commentErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := struct {
+		Message   string `json:"message"`
+		CommentID string `json:"comment_id"`
+		TaskID    string `json:"task_id"`
+	}{
+		Message:   "Комментарий удален",
+		CommentID: commentID,
+		TaskID:    taskID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// notifyComment отправляет событие о новом комментарии участникам задачи
+func (a *App) notifyComment(comment models.Comment) {
+	if a.kafkaProducer == nil {
+		return
+	}
+
+	notifications, err := controllers.GetCommentRecipientsDataBase(a.db, &comment)
+	if err != nil {
+		log.Printf("Ошибка поиска получателей комментария %s: %v", comment.ID, err)
+		return
+	}
+
+	for i := range notifications {
+		if err := a.kafkaProducer.SendNotification(&notifications[i]); err != nil {
+			log.Printf("Ошибка отправки уведомления о комментарии %s: %v", comment.ID, err)
+		}
+	}
+}
+
+func decodeComment(r *http.Request) (*models.Comment, error) {
+	comment := &models.Comment{}
+	if err := json.NewDecoder(r.Body).Decode(comment); err != nil {
+		return nil, errInvalidJSON
+	}
+
+	if err := comment.Validate(); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrCommentForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, controllers.ErrCommentNotFound):
+		return http.StatusNotFound
+	default:
+		return taskErrorStatus(err)
+	}
+}
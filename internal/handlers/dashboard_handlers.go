@@ -4,9 +4,14 @@ import (
 	"TaskManager/internal/controllers"
 	"TaskManager/internal/models"
 	"TaskManager/internal/services"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/bcrypt"
@@ -40,17 +45,85 @@ func (a *App) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, err := parseTaskFilter(r)
+	if err == nil {
+		filter.Location, err = controllers.GetUserLocation(a.db, &userClaims.UserID)
+	}
+	if err == nil {
+		err = filter.Validate()
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
 	// Получение задач из БД
-	tasks, err := controllers.GetTasksDataBase(&userClaims.UserID, a.db)
+	tasks, nextCursor, err := controllers.GetTasksDataBase(&userClaims.UserID, a.db, filter)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusUnauthorized)
+		log.Printf("Ошибка получения задач: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске задач пользователя"})
 		return
 	}
 
+	response := struct {
+		Tasks      []models.Task `json:"tasks"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}{
+		Tasks:      tasks,
+		NextCursor: nextCursor,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tasks)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseTaskFilter читает параметры фильтрации из query string
+func parseTaskFilter(r *http.Request) (*models.TaskFilter, error) {
+	query := r.URL.Query()
+
+	filter := &models.TaskFilter{
+		Project:         query.Get("project"),
+		IncludeArchived: query.Get("include_archived") == "true",
+		Statuses:        splitQueryList(query.Get("status")),
+		Priorities:      splitQueryList(query.Get("priority")),
+		TagsAny:         splitQueryList(query.Get("tags_any")),
+		TagsAll:         splitQueryList(query.Get("tags_all")),
+		Query:           strings.TrimSpace(query.Get("q")),
+		Overdue:         query.Get("overdue") == "true",
+		Sort:            query.Get("sort"),
+		Order:           strings.ToLower(query.Get("order")),
+		Cursor:          query.Get("cursor"),
+	}
+
+	if dueFrom := query.Get("due_from"); dueFrom != "" {
+		filter.DueFrom = &dueFrom
+	}
+	if dueTo := query.Get("due_to"); dueTo != "" {
+		filter.DueTo = &dueTo
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, errors.New("limit must be a number")
+		}
+		filter.Limit = value
+	}
+
+	return filter, nil
+}
+
+// splitQueryList разбирает список значений через запятую
+func splitQueryList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
 }
 
 // Обработчик получения задачи
@@ -79,27 +152,63 @@ func (a *App) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Получение задач из БД
 	taskData, err := controllers.GetTaskDataBase(a.db, &userClaims.UserID, &parts[0])
+	if errors.Is(err, controllers.ErrTaskNotFound) || errors.Is(err, controllers.ErrTaskForbidden) {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusUnauthorized)
+		log.Printf("Ошибка получения задачи: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске задачи пользователя"})
 		return
 	}
 
-	response := struct {
-		Title       string  `json:"title"`
-		Description string  `json:"description"`
-		Priority    string  `json:"priority"`
-		DueDate     *string `json:"due_date"`
-	}{
-		Title:       taskData.Title,
-		Description: taskData.Description,
-		Priority:    taskData.Priority,
-		DueDate:     taskData.DueDate,
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(taskData.Version))
+	json.NewEncoder(w).Encode(taskData)
+}
+
+// Обработчик получения подзадач
+func (a *App) GetSubtasksHandler(w http.ResponseWriter, r *http.Request) {
+	// Извлекаем taskId из url
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	// Получение подзадач из БД
+	tasks, err := controllers.GetSubtasksDataBase(a.db, &userClaims.UserID, &parts[0])
+	if errors.Is(err, controllers.ErrTaskNotFound) || errors.Is(err, controllers.ErrTaskForbidden) {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка получения подзадач: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске подзадач"})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(tasks)
 }
 
 // Обработчик создания задачи
@@ -116,42 +225,99 @@ func (a *App) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Повторный запрос с тем же Idempotency-Key не создает вторую задачу
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(idempotencyKey) > 255 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Idempotency-Key не может быть длиннее 255 символов"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	requestHash := sha256.Sum256(body)
+	requestHashHex := hex.EncodeToString(requestHash[:])
+
+	// Повтор отвечает уже созданной задачей до проверок, зависящих от текущих данных
+	if idempotencyKey != "" {
+		existingID, err := controllers.FindIdempotentTaskDataBase(a.db, userClaims.UserID, idempotencyKey, requestHashHex)
+		if err != nil {
+			writeIdempotencyError(w, err)
+			return
+		}
+		if existingID != "" {
+			a.writeCreatedTask(w, userClaims.UserID, existingID, true)
+			return
+		}
+	}
+
 	newTaskData := models.Task{}
 
-	if err := json.NewDecoder(r.Body).Decode(&newTaskData); err != nil {
+	if err := json.Unmarshal(body, &newTaskData); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
 		return
 	}
 
 	// Валидация данных
-	if newTaskData.Title == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Название задачи не может быть пустым"})
+	if err := a.prepareTaskInput(&newTaskData, userClaims.UserID, true); err != nil {
+		writeTaskInputError(w, err)
 		return
 	}
 
 	newTaskData.UserID = userClaims.UserID
-	err := controllers.CreateTaskDataBase(a.db, &newTaskData)
+	replayed, err := controllers.CreateTaskDataBase(a.db, &newTaskData, idempotencyKey, requestHashHex)
+	if errors.Is(err, controllers.ErrParentTaskNotFound) || errors.Is(err, controllers.ErrTaskForbidden) ||
+		errors.Is(err, controllers.ErrProjectNotFound) {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при создании задачи"})
+		writeIdempotencyError(w, err)
 		return
 	}
 
-	response := struct {
-		Message string `json:"message"`
-		TaskID  string `json:"task_id"`
-		UserID  string `json:"user_id"`
-	}{
-		Message: "Задача успешно создана",
-		TaskID:  "temp-id", // Заменится на реальный ID из БД
-		UserID:  userClaims.UserID,
+	a.writeCreatedTask(w, userClaims.UserID, newTaskData.ID, replayed)
+}
+
+// writeCreatedTask отдает созданную задачу в том виде, в каком она сохранена в БД
+func (a *App) writeCreatedTask(w http.ResponseWriter, userID string, taskID string, replayed bool) {
+	task, err := controllers.GetTaskDataBase(a.db, &userID, &taskID)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
 	}
 
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/tasks/"+task.ID)
+	w.Header().Set("ETag", taskETag(task.Version))
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(task)
+}
+
+// writeIdempotencyError ответ на ошибку создания задачи, в том числе по ключу идемпотентности
+func writeIdempotencyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, controllers.ErrIdempotencyKeyReused):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case errors.Is(err, controllers.ErrIdempotentTaskGone):
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при создании задачи"})
+	}
 }
 
 // Обработчик переключения статуса задачи
@@ -174,11 +340,13 @@ func (a *App) ToggleTaskStatusHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	taskID := parts[0]
+	cascade := r.URL.Query().Get("cascade") == "true"
+	force := r.URL.Query().Get("force") == "true"
 
 	// Изменение задачи в БД
-	err := controllers.ToggleTaskStatusDataBase(a.db, &taskID, &userClaims.UserID)
+	err := controllers.ToggleTaskStatusDataBase(a.db, &taskID, &userClaims.UserID, cascade, force)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(taskErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
@@ -221,7 +389,7 @@ func (a *App) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	// Удаление задачи в БД
 	err := controllers.DeleteTaskDataBase(a.db, &taskID, &userClaims.UserID)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(taskErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
@@ -240,6 +408,75 @@ func (a *App) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// normalizeTaskDueDate приводит срок задачи к часовому поясу пользователя
+func (a *App) normalizeTaskDueDate(task *models.Task, userID string) error {
+	loc, err := controllers.GetUserLocation(a.db, &userID)
+	if err != nil {
+		return err
+	}
+
+	task.DueDate, err = models.NormalizeDueDate(task.DueDate, loc)
+	return err
+}
+
+// prepareTaskInput нормализует задачу из запроса и проверяет все её поля.
+// create включает проверки, действующие только при создании задачи.
+func (a *App) prepareTaskInput(task *models.Task, userID string, create bool) error {
+	var errs models.ValidationErrors
+
+	if create && task.Priority == "" {
+		task.Priority = "medium"
+	}
+
+	if err := a.normalizeTaskDueDate(task, userID); err != nil {
+		var fieldErr *models.FieldError
+		if !errors.As(err, &fieldErr) {
+			return err
+		}
+		errs = errs.Add(err)
+	}
+
+	tags, err := models.NormalizeTags(task.Tags)
+	if err != nil {
+		errs = errs.Add(err)
+	} else {
+		task.Tags = tags
+	}
+
+	if create {
+		errs = errs.Add(task.Validate())
+	} else {
+		errs = errs.Add(task.ValidateUpdate())
+	}
+
+	// Срок уже в часовом поясе пользователя: его день становится днем серии
+	if len(errs) == 0 {
+		task.Recurrence.AnchorMonthDay(task.DueDate)
+	}
+
+	return errs.Err()
+}
+
+// writeTaskInputError отвечает 422 со списком ошибок полей или 400 для прочих ошибок
+func writeTaskInputError(w http.ResponseWriter, err error) {
+	var errs models.ValidationErrors
+	if errors.As(err, &errs) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]models.ValidationErrors{"errors": errs})
+		return
+	}
+
+	var fieldErr *models.FieldError
+	if errors.As(err, &fieldErr) {
+		writeTaskInputError(w, models.ValidationErrors{fieldErr})
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
 func (a *App) SaveTaskDataHandler(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
 	parts := strings.Split(path, "/")
@@ -264,29 +501,41 @@ func (a *App) SaveTaskDataHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := controllers.SavaTaskDB(a.db, &userClaims.UserID, &parts[0], &newTaskData)
+	if err := a.prepareTaskInput(&newTaskData, userClaims.UserID, false); err != nil {
+		writeTaskInputError(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	response := struct {
-		Message string `json:"message"`
-		TaskID  string `json:"task_id"`
-		UserID  string `json:"user_id"`
-	}{
-		Message: "Задача изменена",
-		TaskID:  parts[0],
-		UserID:  userClaims.UserID,
+	err = controllers.SavaTaskDB(a.db, &userClaims.UserID, &parts[0], &newTaskData, expectedVersion)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Как и PATCH, отдаем сохраненную задачу с новой версией для следующего If-Match
+	task, err := controllers.GetTaskDataBase(a.db, &userClaims.UserID, &parts[0])
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("ETag", taskETag(task.Version))
+	json.NewEncoder(w).Encode(task)
 }
 
 func (a *App) SaveUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
-	user_id := r.Context().Value("user").(*services.Claims).UserID
+	userClaims := r.Context().Value("user").(*services.Claims)
+	user_id := userClaims.UserID
 	body := struct {
 		CurrentPassword string `json:"current_password"`
 		NewPassword     string `json:"new_password"`
@@ -337,6 +586,7 @@ func (a *App) SaveUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "ошибка при смене пароля"})
+		return
 	}
 
 	query2 := `
@@ -350,6 +600,13 @@ func (a *App) SaveUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Остальные устройства должны войти заново с новым паролем
+	if err = controllers.RevokeOtherSessionsDataBase(tx, user_id, userClaims.SessionID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ошибка при смене пароля"})
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "ошибка при смене пароля"})
@@ -426,3 +683,30 @@ func (a *App) SaveUserEmailHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"result": "почта изменена"})
 }
+
+func (a *App) SaveUserTimezoneHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	user_id := r.Context().Value("user").(*services.Claims).UserID
+	body := struct {
+		Timezone string `json:"timezone"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	if err := controllers.SaveUserTimezoneDataBase(a.db, &user_id, body.Timezone); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"result": "часовой пояс изменен"})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик зависимостей задачи: /api/tasks/{id}/dependencies[/{blockerId}]
+func (a *App) TaskDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) < 2 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	taskID := parts[0]
+	blockerID := ""
+	if len(parts) > 2 {
+		blockerID = parts[2]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && blockerID == "":
+		a.getTaskDependencies(w, userClaims, taskID)
+	case r.Method == http.MethodPost && blockerID == "":
+		a.addTaskDependency(w, r, userClaims, taskID)
+	case r.Method == http.MethodDelete && blockerID != "":
+		a.deleteTaskDependency(w, userClaims, taskID, blockerID)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func (a *App) getTaskDependencies(w http.ResponseWriter, userClaims *services.Claims, taskID string) {
+	dependencies, err := controllers.GetTaskDependenciesDataBase(a.db, &userClaims.UserID, &taskID)
+	if err != nil {
+		w.WriteHeader(dependencyErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dependencies)
+}
+
+func (a *App) addTaskDependency(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string) {
+	var request models.TaskDependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	if err := request.Validate(taskID); err != nil {
+		writeTaskInputError(w, err)
+		return
+	}
+
+	if err := controllers.AddTaskDependencyDataBase(a.db, &userClaims.UserID, &taskID, &request.BlockerID); err != nil {
+		w.WriteHeader(dependencyErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	a.getTaskDependencies(w, userClaims, taskID)
+}
+
+func (a *App) deleteTaskDependency(w http.ResponseWriter, userClaims *services.Claims, taskID string, blockerID string) {
+	if err := controllers.DeleteTaskDependencyDataBase(a.db, &userClaims.UserID, &taskID, &blockerID); err != nil {
+		w.WriteHeader(dependencyErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := struct {
+		Message   string `json:"message"`
+		TaskID    string `json:"task_id"`
+		BlockerID string `json:"blocker_id"`
+	}{
+		Message:   "Зависимость удалена",
+		TaskID:    taskID,
+		BlockerID: blockerID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func dependencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrBlockerNotFound):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, controllers.ErrDependencyCycle):
+		return http.StatusConflict
+	case errors.Is(err, controllers.ErrDependencyNotFound):
+		return http.StatusNotFound
+	default:
+		return taskErrorStatus(err)
+	}
+}
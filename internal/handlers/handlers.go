@@ -2,28 +2,76 @@ package handlers
 
 import (
 	"TaskManager/internal/config"
+	"TaskManager/internal/controllers"
 	"TaskManager/internal/models"
 	"TaskManager/internal/services"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+var errInvalidJSON = errors.New("Неверный JSON")
+
+// parsePageLimit читает размер страницы из параметра limit
+func parsePageLimit(r *http.Request, defaultLimit int, maxLimit int) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+
+	return limit, nil
+}
+
 type App struct {
-	db         *sql.DB
-	cfg        *config.Config
-	jwtService *services.JWTService
+	db            *sql.DB
+	cfg           *config.Config
+	jwtService    *services.JWTService
+	kafkaProducer *services.KafkaProducer
+	loginPolicy   models.LoginThrottlePolicy
 }
 
-func NewApp(db *sql.DB, cfg *config.Config, jwtService *services.JWTService) *App {
+func NewApp(db *sql.DB, cfg *config.Config, jwtService *services.JWTService, kafkaProducer *services.KafkaProducer) *App {
 	return &App{
-		db:         db,
-		cfg:        cfg,
-		jwtService: jwtService,
+		db:            db,
+		cfg:           cfg,
+		jwtService:    jwtService,
+		kafkaProducer: kafkaProducer,
+		loginPolicy:   loginThrottlePolicy(cfg),
+	}
+}
+
+// loginThrottlePolicy параметры защиты входа из конфигурации
+func loginThrottlePolicy(cfg *config.Config) models.LoginThrottlePolicy {
+	parse := func(value string, defaultValue time.Duration) time.Duration {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+		return defaultValue // значение по умолчанию
+	}
+
+	return models.LoginThrottlePolicy{
+		MaxLoginFailures: cfg.LoginMaxFailures,
+		MaxIPFailures:    cfg.LoginIPMaxFailures,
+		BackoffBase:      parse(cfg.LoginBackoffBase, time.Second),
+		BackoffMax:       parse(cfg.LoginBackoffMax, time.Minute),
+		Lockout:          parse(cfg.LoginLockoutDuration, 15*time.Minute),
+		Window:           parse(cfg.LoginFailureWindow, 15*time.Minute),
+		AttemptHold:      10 * time.Second, // с запасом на проверку пароля и запросы к БД
+		IPAttemptHold:    time.Second,      // ограничивает параллельный перебор разных логинов, не задерживая соседей по NAT
 	}
 }
 
-// Обработчик обновления токена
+// Обработчик обновления токена: обменивает refresh токен на новую пару токенов
 func (a *App) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -31,26 +79,85 @@ func (a *App) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Token string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
 		http.Error(w, "Неверный JSON", http.StatusBadRequest)
 		return
 	}
 
-	newToken, err := a.jwtService.RefreshToken(req.Token)
+	refreshToken, refreshHash, err := services.GenerateRefreshToken()
 	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка генерации токена"})
+		return
+	}
+
+	user, sessionID, err := controllers.RotateRefreshTokenDataBase(a.db, services.HashRefreshToken(req.RefreshToken),
+		refreshHash, a.jwtService.RefreshTTL())
+	if err != nil {
+		if errors.Is(err, controllers.ErrRefreshTokenReused) {
+			log.Printf("Повторное использование refresh токена пользователя %s, сессия %s отозвана", user.ID, sessionID)
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидный токен"})
 		return
 	}
 
+	a.writeTokens(w, &user, sessionID, refreshToken)
+}
+
+// Обработчик выхода: завершает сессию refresh токена
+func (a *App) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := controllers.RevokeRefreshTokenDataBase(a.db, services.HashRefreshToken(req.RefreshToken)); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при выходе"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Выход выполнен"})
+}
+
+// writeTokens выпускает access токен сессии и отдает его вместе с refresh токеном
+func (a *App) writeTokens(w http.ResponseWriter, user *models.User, sessionID string, refreshToken string) {
+	token, err := a.jwtService.GenerateToken(user, sessionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка генерации токена"})
+		return
+	}
+
 	response := struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
 	}{
-		Token: newToken,
+		Token:        token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(a.jwtService.AccessTTL().Seconds()),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -74,8 +181,8 @@ func (a *App) MeHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем полную информацию о пользователе из БД
 	var dbUser models.User
-	err := a.db.QueryRow("SELECT id, login, email, create_at FROM users WHERE id = $1", userClaims.UserID).Scan(
-		&dbUser.ID, &dbUser.Login, &dbUser.Email, &dbUser.CreateAt,
+	err := a.db.QueryRow("SELECT id, login, email, timezone, create_at FROM users WHERE id = $1", userClaims.UserID).Scan(
+		&dbUser.ID, &dbUser.Login, &dbUser.Email, &dbUser.Timezone, &dbUser.CreateAt,
 	)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
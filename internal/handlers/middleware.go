@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"TaskManager/internal/controllers"
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -36,6 +39,19 @@ func (a *App) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Токен завершенной сессии больше не принимается
+		if err = controllers.CheckSessionDataBase(a.db, claims.UserID, claims.SessionID); err != nil {
+			if errors.Is(err, controllers.ErrSessionRevoked) {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка проверки сессии"})
+			return
+		}
+
 		// Добавляем информацию о пользователе в контекст запроса
 		ctx := context.WithValue(r.Context(), "user", claims)
 		next(w, r.WithContext(ctx))
@@ -46,8 +62,9 @@ func (a *App) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 func enableCORS(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location, Idempotent-Replayed, Retry-After")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -107,3 +124,22 @@ func (a *App) ApiMiddleware(next http.HandlerFunc) http.HandlerFunc {
 func (a *App) ProtectedApiMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return panicRecoveryMiddleware(loggingMiddleware(enableCORS(a.authMiddleware(next))))
 }
+
+// clientIP адрес клиента из соединения. Заголовкам прокси не доверяем,
+// их может подставить сам клиент.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// truncateString обрезает строку до max символов
+func truncateString(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max])
+}
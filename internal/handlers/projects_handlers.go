@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик списка проектов: /api/projects
+func (a *App) ProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		includeArchived := r.URL.Query().Get("archived") == "true"
+
+		projects, err := controllers.GetProjectsDataBase(a.db, &userClaims.UserID, includeArchived)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске проектов пользователя"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(projects)
+	case http.MethodPost:
+		project := models.Project{}
+		if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+			return
+		}
+
+		if err := project.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		project.UserID = userClaims.UserID
+		if err := controllers.CreateProjectDataBase(a.db, &project); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при создании проекта"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(project)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+// Обработчик проекта: /api/projects/{id}
+func (a *App) ProjectHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/projects/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID проекта не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	projectID := parts[0]
+
+	switch r.Method {
+	case http.MethodGet:
+		project, err := controllers.GetProjectDataBase(a.db, &userClaims.UserID, &projectID)
+		if err != nil {
+			w.WriteHeader(projectErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(project)
+	case http.MethodPut:
+		project := models.Project{}
+		if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+			return
+		}
+
+		if err := project.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if err := controllers.SaveProjectDataBase(a.db, &userClaims.UserID, &projectID, &project); err != nil {
+			w.WriteHeader(projectErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		saved, err := controllers.GetProjectDataBase(a.db, &userClaims.UserID, &projectID)
+		if err != nil {
+			w.WriteHeader(projectErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(saved)
+	case http.MethodDelete:
+		if err := controllers.DeleteProjectDataBase(a.db, &userClaims.UserID, &projectID); err != nil {
+			w.WriteHeader(projectErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		response := struct {
+			Message   string `json:"message"`
+			ProjectID string `json:"project_id"`
+		}{
+			Message:   "Проект удален",
+			ProjectID: projectID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func projectErrorStatus(err error) int {
+	if errors.Is(err, controllers.ErrProjectNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик напоминаний задачи: /api/tasks/{id}/reminders[/{reminderId}]
+func (a *App) TaskRemindersHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) < 2 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	taskID := parts[0]
+	reminderID := ""
+	if len(parts) > 2 {
+		reminderID = parts[2]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && reminderID == "":
+		a.getReminders(w, userClaims, taskID)
+	case r.Method == http.MethodPost && reminderID == "":
+		a.createReminder(w, r, userClaims, taskID)
+	case r.Method == http.MethodPut && reminderID != "":
+		a.saveReminder(w, r, userClaims, taskID, reminderID)
+	case r.Method == http.MethodDelete && reminderID != "":
+		a.deleteReminder(w, userClaims, taskID, reminderID)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func (a *App) getReminders(w http.ResponseWriter, userClaims *services.Claims, taskID string) {
+	reminders, err := controllers.GetRemindersDataBase(a.db, &userClaims.UserID, &taskID)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reminders)
+}
+
+func (a *App) createReminder(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string) {
+	reminder, err := a.decodeReminder(r, userClaims)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := controllers.CreateReminderDataBase(a.db, &userClaims.UserID, &taskID, reminder); err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reminder)
+}
+
+func (a *App) saveReminder(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string, reminderID string) {
+	reminder, err := a.decodeReminder(r, userClaims)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := controllers.SaveReminderDataBase(a.db, &userClaims.UserID, &taskID, &reminderID, reminder); err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reminder)
+}
+
+func (a *App) deleteReminder(w http.ResponseWriter, userClaims *services.Claims, taskID string, reminderID string) {
+	if err := controllers.DeleteReminderDataBase(a.db, &userClaims.UserID, &taskID, &reminderID); err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := struct {
+		Message    string `json:"message"`
+		ReminderID string `json:"reminder_id"`
+		TaskID     string `json:"task_id"`
+	}{
+		Message:    "Напоминание удалено",
+		ReminderID: reminderID,
+		TaskID:     taskID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// decodeReminder читает напоминание из тела запроса, время приводится к часовому поясу пользователя
+func (a *App) decodeReminder(r *http.Request, userClaims *services.Claims) (*models.Reminder, error) {
+	reminder := &models.Reminder{}
+	if err := json.NewDecoder(r.Body).Decode(reminder); err != nil {
+		return nil, errInvalidJSON
+	}
+
+	loc, err := controllers.GetUserLocation(a.db, &userClaims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reminder.RemindAt, err = models.NormalizeDueDate(reminder.RemindAt, loc); err != nil {
+		return nil, errors.New("remind_at must be in format YYYY-MM-DDTHH:MM or RFC 3339")
+	}
+
+	if err := reminder.Validate(); err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик списка сохраненных представлений: /api/views
+func (a *App) SavedViewsHandler(w http.ResponseWriter, r *http.Request) {
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		views, err := controllers.GetSavedViewsDataBase(a.db, &userClaims.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске представлений пользователя"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(views)
+	case http.MethodPost:
+		view := models.SavedView{}
+		if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+			return
+		}
+
+		if err := view.Validate(); err != nil {
+			writeTaskInputError(w, err)
+			return
+		}
+
+		view.UserID = userClaims.UserID
+		if err := controllers.CreateSavedViewDataBase(a.db, &view); err != nil {
+			w.WriteHeader(savedViewErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(view)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+// Обработчик представления: /api/views/{id} и /api/views/{id}/tasks
+func (a *App) SavedViewHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/views/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID представления не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	viewID := parts[0]
+
+	if len(parts) > 1 && parts[1] == "tasks" {
+		a.savedViewTasks(w, r, userClaims, viewID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		view, err := controllers.GetSavedViewDataBase(a.db, &userClaims.UserID, &viewID)
+		if err != nil {
+			w.WriteHeader(savedViewErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+	case http.MethodPut:
+		view := models.SavedView{}
+		if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+			return
+		}
+
+		if err := view.Validate(); err != nil {
+			writeTaskInputError(w, err)
+			return
+		}
+
+		if err := controllers.SaveSavedViewDataBase(a.db, &userClaims.UserID, &viewID, &view); err != nil {
+			w.WriteHeader(savedViewErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		saved, err := controllers.GetSavedViewDataBase(a.db, &userClaims.UserID, &viewID)
+		if err != nil {
+			w.WriteHeader(savedViewErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(saved)
+	case http.MethodDelete:
+		if err := controllers.DeleteSavedViewDataBase(a.db, &userClaims.UserID, &viewID); err != nil {
+			w.WriteHeader(savedViewErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		response := struct {
+			Message string `json:"message"`
+			ViewID  string `json:"view_id"`
+		}{
+			Message: "Представление удалено",
+			ViewID:  viewID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+// savedViewTasks выполняет сохраненный фильтр так же, как GET /api/tasks.
+// Из запроса берутся только limit и cursor.
+func (a *App) savedViewTasks(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, viewID string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	view, err := controllers.GetSavedViewDataBase(a.db, &userClaims.UserID, &viewID)
+	if err != nil {
+		w.WriteHeader(savedViewErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	location, err := controllers.GetUserLocation(a.db, &userClaims.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при получении часового пояса"})
+		return
+	}
+
+	filter := view.Filters.TaskFilter(location)
+	filter.Cursor = r.URL.Query().Get("cursor")
+	filter.Limit, err = parsePageLimit(r, models.DefaultTasksLimit, models.MaxTasksLimit)
+	if err == nil {
+		err = filter.Validate()
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	tasks, nextCursor, err := controllers.GetTasksDataBase(&userClaims.UserID, a.db, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске задач пользователя"})
+		return
+	}
+
+	response := struct {
+		View       models.SavedView `json:"view"`
+		Tasks      []models.Task    `json:"tasks"`
+		NextCursor string           `json:"next_cursor,omitempty"`
+	}{
+		View:       view,
+		Tasks:      tasks,
+		NextCursor: nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func savedViewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrSavedViewNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, controllers.ErrSavedViewNameTaken),
+		errors.Is(err, controllers.ErrSavedViewsLimit):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"net/http"
+)
+
+// Обработчик полнотекстового поиска задач: GET /api/search?q=
+func (a *App) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	limit, err := parsePageLimit(r, models.DefaultSearchLimit, models.MaxSearchLimit)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	request := models.SearchRequest{
+		Query:  r.URL.Query().Get("q"),
+		Limit:  limit,
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+
+	if err := request.Validate(); err != nil {
+		writeTaskInputError(w, err)
+		return
+	}
+
+	results, nextCursor, err := controllers.SearchTasksDataBase(a.db, &userClaims.UserID, &request)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске задач"})
+		return
+	}
+
+	response := struct {
+		Results    []models.SearchResult `json:"results"`
+		NextCursor string                `json:"next_cursor,omitempty"`
+	}{
+		Results:    results,
+		NextCursor: nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик сессий пользователя: /api/user/sessions[/{id}]
+func (a *App) UserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	sessionID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/user/sessions"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && sessionID == "":
+		sessions, err := controllers.GetSessionsDataBase(a.db, &userClaims.UserID, userClaims.SessionID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при получении сессий"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sessions)
+	case r.Method == http.MethodDelete && sessionID != "":
+		if err := controllers.RevokeSessionDataBase(a.db, &userClaims.UserID, &sessionID); err != nil {
+			w.WriteHeader(sessionErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		response := struct {
+			Message   string `json:"message"`
+			SessionID string `json:"session_id"`
+		}{
+			Message:   "Сессия завершена",
+			SessionID: sessionID,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func sessionErrorStatus(err error) int {
+	if errors.Is(err, controllers.ErrSessionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик участников задачи: /api/tasks/{id}/members[/{userId}]
+func (a *App) TaskMembersHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) < 2 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	taskID := parts[0]
+	memberID := ""
+	if len(parts) > 2 {
+		memberID = parts[2]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && memberID == "":
+		a.getTaskMembers(w, userClaims, taskID)
+	case r.Method == http.MethodPost && memberID == "":
+		a.addTaskMember(w, r, userClaims, taskID)
+	case r.Method == http.MethodDelete && memberID != "":
+		a.deleteTaskMember(w, userClaims, taskID, memberID)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func (a *App) getTaskMembers(w http.ResponseWriter, userClaims *services.Claims, taskID string) {
+	members, err := controllers.GetTaskMembersDataBase(a.db, &userClaims.UserID, &taskID)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+func (a *App) addTaskMember(w http.ResponseWriter, r *http.Request, userClaims *services.Claims, taskID string) {
+	member := models.TaskMember{}
+	if err := json.NewDecoder(r.Body).Decode(&member); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	if member.Login == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Логин участника не может быть пустым"})
+		return
+	}
+
+	if err := member.ValidateRole(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := controllers.AddTaskMemberDataBase(a.db, &userClaims.UserID, &taskID, &member); err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(member)
+}
+
+func (a *App) deleteTaskMember(w http.ResponseWriter, userClaims *services.Claims, taskID string, memberID string) {
+	if err := controllers.DeleteTaskMemberDataBase(a.db, &userClaims.UserID, &taskID, &memberID); err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+		TaskID  string `json:"task_id"`
+		UserID  string `json:"user_id"`
+	}{
+		Message: "Участник удален",
+		TaskID:  taskID,
+		UserID:  memberID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// taskErrorStatus подбирает HTTP статус для ошибки операции с задачей
+func taskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrTaskForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, controllers.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, controllers.ErrTaskVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, controllers.ErrTaskBlocked):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
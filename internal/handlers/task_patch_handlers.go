@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// patchRetries сколько раз PATCH без If-Match повторяется при параллельном изменении задачи
+const patchRetries = 3
+
+// taskPatchDocument поля задачи, доступные для изменения через PATCH
+type taskPatchDocument struct {
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Priority    string             `json:"priority"`
+	DueDate     *string            `json:"due_date"`
+	Recurrence  *models.Recurrence `json:"recurrence"`
+	ProjectID   *string            `json:"project_id"`
+	Tags        []string           `json:"tags"`
+}
+
+// Обработчик частичного изменения задачи: PATCH /api/tasks/{id} (JSON Merge Patch)
+func (a *App) PatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ожидается application/merge-patch+json"})
+			return
+		}
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	patch, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil || !strings.HasPrefix(strings.TrimSpace(string(patch)), "{") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	taskID := parts[0]
+	for attempt := 1; ; attempt++ {
+		current, err := controllers.GetTaskDataBase(a.db, &userClaims.UserID, &taskID)
+		if err != nil {
+			w.WriteHeader(taskErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		if expectedVersion != nil && *expectedVersion != current.Version {
+			err = controllers.ErrTaskVersionMismatch
+		}
+
+		var patched *models.Task
+		if err == nil {
+			patched, err = a.applyTaskPatch(&current, patch, userClaims.UserID)
+			if err != nil {
+				writeTaskInputError(w, err)
+				return
+			}
+
+			// Сохраняем, только если задача не изменилась с момента чтения
+			err = controllers.SavaTaskDB(a.db, &userClaims.UserID, &taskID, patched, &current.Version)
+		}
+
+		// Без If-Match клиент не знает версию, поэтому параллельное изменение просто применяем заново
+		if errors.Is(err, controllers.ErrTaskVersionMismatch) && expectedVersion == nil && attempt < patchRetries {
+			continue
+		}
+		if err != nil {
+			w.WriteHeader(taskErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		break
+	}
+
+	task, err := controllers.GetTaskDataBase(a.db, &userClaims.UserID, &taskID)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(task.Version))
+	json.NewEncoder(w).Encode(task)
+}
+
+// applyTaskPatch применяет патч к редактируемым полям задачи и проверяет результат
+func (a *App) applyTaskPatch(current *models.Task, patch []byte, userID string) (*models.Task, error) {
+	document, err := json.Marshal(taskPatchDocument{
+		Title:       current.Title,
+		Description: current.Description,
+		Priority:    current.Priority,
+		DueDate:     current.DueDate,
+		Recurrence:  current.Recurrence,
+		ProjectID:   current.ProjectID,
+		Tags:        current.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := models.MergePatch(document, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{}
+	if err = json.Unmarshal(merged, task); err != nil {
+		return nil, errInvalidJSON
+	}
+
+	// null в tags означает удаление всех тегов
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+
+	if err = a.prepareTaskInput(task, userID, false); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// taskETag строит ETag задачи по её версии
+func taskETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch читает ожидаемую версию задачи из заголовка If-Match.
+// Отсутствующий заголовок и * означают любую версию.
+func parseIfMatch(r *http.Request) (*int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return nil, errors.New("If-Match must contain an ETag of the task")
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, errors.New("If-Match must contain an ETag of the task")
+	}
+
+	return &version, nil
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/services"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик корзины: /api/tasks/trash
+func (a *App) TrashHandler(w http.ResponseWriter, r *http.Request) {
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tasks, err := controllers.GetTrashDataBase(a.db, &userClaims.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при поиске задач в корзине"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tasks)
+	case http.MethodDelete:
+		purged, err := controllers.EmptyTrashDataBase(a.db, &userClaims.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при очистке корзины"})
+			return
+		}
+
+		response := struct {
+			Message string `json:"message"`
+			Purged  int64  `json:"purged"`
+		}{
+			Message: "Корзина очищена",
+			Purged:  purged,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+// Обработчик восстановления задачи: POST /api/tasks/{id}/restore
+func (a *App) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	a.trashTaskAction(w, r, http.MethodPost, controllers.RestoreTaskDataBase, "Задача восстановлена")
+}
+
+// Обработчик окончательного удаления задачи: DELETE /api/tasks/{id}/purge
+func (a *App) PurgeTaskHandler(w http.ResponseWriter, r *http.Request) {
+	a.trashTaskAction(w, r, http.MethodDelete, controllers.PurgeTaskDataBase, "Задача удалена окончательно")
+}
+
+func (a *App) trashTaskAction(w http.ResponseWriter, r *http.Request, method string,
+	action func(db *sql.DB, UserID *string, TaskID *string) error, message string) {
+	if r.Method != method {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	taskID := parts[0]
+	if err := action(a.db, &userClaims.UserID, &taskID); err != nil {
+		w.WriteHeader(trashErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+		TaskID  string `json:"task_id"`
+		UserID  string `json:"user_id"`
+	}{
+		Message: message,
+		TaskID:  taskID,
+		UserID:  userClaims.UserID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func trashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrTaskNotInTrash):
+		return http.StatusNotFound
+	case errors.Is(err, controllers.ErrParentTaskInTrash):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
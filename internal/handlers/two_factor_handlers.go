@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Обработчик двухфакторной аутентификации: /api/user/2fa[/enroll|/confirm|/recovery-codes]
+func (a *App) TwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/user/2fa"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && action == "":
+		status, err := controllers.GetTwoFactorStatusDataBase(a.db, &userClaims.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при получении настроек 2FA"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case r.Method == http.MethodPost && action == "enroll":
+		secret, err := services.GenerateTOTPSecret()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка генерации секрета"})
+			return
+		}
+
+		if err = controllers.EnrollTwoFactorDataBase(a.db, &userClaims.UserID, secret); err != nil {
+			w.WriteHeader(twoFactorErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.TwoFactorEnrollment{
+			Secret:     secret,
+			OTPAuthURI: services.TOTPURI(userClaims.Login, secret),
+		})
+	case r.Method == http.MethodPost && (action == "confirm" || action == "recovery-codes"):
+		code, ok := decodeTwoFactorCode(w, r)
+		if !ok {
+			return
+		}
+
+		codes, hashes, err := services.GenerateRecoveryCodes()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка генерации кодов восстановления"})
+			return
+		}
+
+		ok = a.checkTwoFactorCode(w, r, userClaims.Login, func() error {
+			if action == "confirm" {
+				return controllers.ConfirmTwoFactorDataBase(a.db, &userClaims.UserID, code, hashes)
+			}
+			return controllers.RegenerateRecoveryCodesDataBase(a.db, &userClaims.UserID, code, hashes)
+		})
+		if !ok {
+			return
+		}
+
+		// Коды показываются один раз, в БД хранятся только хеши
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+	case r.Method == http.MethodDelete && action == "":
+		code, ok := decodeTwoFactorCode(w, r)
+		if !ok {
+			return
+		}
+
+		ok = a.checkTwoFactorCode(w, r, userClaims.Login, func() error {
+			return controllers.DisableTwoFactorDataBase(a.db, &userClaims.UserID, code)
+		})
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Двухфакторная аутентификация отключена"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+// checkTwoFactorCode выполняет действие, проверяющее код, под тем же ограничением попыток, что и вход:
+// иначе с украденным access токеном коды можно перебрать. При ошибке пишет ответ.
+func (a *App) checkTwoFactorCode(w http.ResponseWriter, r *http.Request, login string, action func() error) bool {
+	ip := clientIP(r)
+	reservation, ok := a.reserveLoginAttempt(w, login, ip)
+	if !ok {
+		return false
+	}
+
+	err := action()
+	if errors.Is(err, controllers.ErrTwoFactorCodeInvalid) {
+		if err := controllers.RecordLoginFailureDataBase(a.db, a.loginPolicy, login, ip); err != nil {
+			log.Printf("Ошибка учета неверного кода 2FA: %v", err)
+		}
+	} else {
+		a.releaseLoginAttempt(login, ip, reservation)
+	}
+	if err != nil {
+		w.WriteHeader(twoFactorErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// Обработчик второго шага входа: токен первого шага и код TOTP (или код восстановления)
+func (a *App) LoginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный JSON"})
+		return
+	}
+
+	tokenHash := services.HashLoginChallenge(req.ChallengeToken)
+	login, err := controllers.GetLoginChallengeLoginDataBase(a.db, tokenHash)
+	if err != nil {
+		writeLoginChallengeError(w, err)
+		return
+	}
+
+	// Перебор кодов ограничивается тем же счетчиком, что и перебор паролей
+	ip := clientIP(r)
+	reservation, ok := a.reserveLoginAttempt(w, login, ip)
+	if !ok {
+		return
+	}
+
+	user, err := controllers.CompleteLoginChallengeDataBase(a.db, tokenHash, strings.TrimSpace(req.Code))
+	if errors.Is(err, controllers.ErrTwoFactorCodeInvalid) {
+		if err := controllers.RecordLoginFailureDataBase(a.db, a.loginPolicy, login, ip); err != nil {
+			log.Printf("Ошибка учета неудачного входа: %v", err)
+		}
+		writeLoginChallengeError(w, err)
+		return
+	}
+	if err != nil {
+		a.releaseLoginAttempt(login, ip, reservation)
+		writeLoginChallengeError(w, err)
+		return
+	}
+
+	if err = controllers.ResetLoginThrottleDataBase(a.db, login, ip, reservation); err != nil {
+		log.Printf("Ошибка сброса счетчика входа: %v", err)
+	}
+
+	a.startSession(w, r, &user)
+}
+
+// writeLoginChallengeError ответ на неудачный второй шаг входа
+func writeLoginChallengeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, controllers.ErrLoginChallengeInvalid) || errors.Is(err, controllers.ErrTwoFactorCodeInvalid) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка проверки кода"})
+}
+
+// writeLoginChallenge отдает токен второго шага входа вместо access токена
+func (a *App) writeLoginChallenge(w http.ResponseWriter, user *models.User) {
+	ttl, err := time.ParseDuration(a.cfg.LoginChallengeTTL)
+	if err != nil {
+		ttl = 5 * time.Minute // значение по умолчанию
+	}
+
+	token, tokenHash, err := services.GenerateLoginChallenge()
+	if err == nil {
+		err = controllers.CreateLoginChallengeDataBase(a.db, user.ID, tokenHash, ttl)
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка генерации токена"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LoginChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(ttl.Seconds()),
+	})
+}
+
+// decodeTwoFactorCode читает {"code": "..."} из тела запроса
+func decodeTwoFactorCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Укажите код подтверждения"})
+		return "", false
+	}
+
+	return strings.TrimSpace(req.Code), true
+}
+
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrTwoFactorCodeInvalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, controllers.ErrTwoFactorEnabled),
+		errors.Is(err, controllers.ErrTwoFactorNotEnabled),
+		errors.Is(err, controllers.ErrTwoFactorNotEnrolled):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
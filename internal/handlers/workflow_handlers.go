@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Обработчик процесса пользователя: /api/workflow
+func (a *App) WorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.writeWorkflow(w, userClaims.UserID)
+	case http.MethodPut:
+		var workflow models.Workflow
+		if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Неверный формат JSON"})
+			return
+		}
+
+		if err := workflow.Validate(); err != nil {
+			writeTaskInputError(w, err)
+			return
+		}
+
+		if err := controllers.SaveWorkflowDataBase(a.db, &userClaims.UserID, &workflow); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при сохранении процесса"})
+			return
+		}
+
+		a.writeWorkflow(w, userClaims.UserID)
+	case http.MethodDelete:
+		if err := controllers.DeleteWorkflowDataBase(a.db, &userClaims.UserID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при удалении процесса"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Процесс удален"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+	}
+}
+
+func (a *App) writeWorkflow(w http.ResponseWriter, UserID string) {
+	workflow, err := controllers.GetWorkflowDataBase(a.db, &UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Ошибка при получении процесса"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// Обработчик перехода задачи в состояние: POST /api/tasks/{id}/transition
+func (a *App) TransitionTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ID задачи не указан"})
+		return
+	}
+
+	userClaims, ok := r.Context().Value("user").(*services.Claims)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Невалидные данные пользователя"})
+		return
+	}
+
+	var request struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Неверный формат JSON"})
+		return
+	}
+
+	request.State = strings.TrimSpace(request.State)
+	if request.State == "" {
+		writeTaskInputError(w, &models.FieldError{
+			Field:   "state",
+			Code:    models.CodeRequired,
+			Message: "state is required",
+		})
+		return
+	}
+
+	taskID := parts[0]
+	// force=true завершает задачу с незавершенными блокирующими задачами
+	force := r.URL.Query().Get("force") == "true"
+
+	if err := controllers.TransitionTaskDataBase(a.db, &userClaims.UserID, &taskID, request.State, force); err != nil {
+		w.WriteHeader(workflowErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	task, err := controllers.GetTaskDataBase(a.db, &userClaims.UserID, &taskID)
+	if err != nil {
+		w.WriteHeader(taskErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(task.Version))
+	json.NewEncoder(w).Encode(task)
+}
+
+func workflowErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, controllers.ErrWorkflowStateNotFound):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, controllers.ErrTransitionNotAllowed):
+		return http.StatusConflict
+	default:
+		return taskErrorStatus(err)
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"math"
+	"strings"
+)
+
+// BoardStatuses колонки доски пользователя без процесса, в порядке отображения
+var BoardStatuses = []string{"active", "completed"}
+
+// MinPositionGap минимальный зазор между соседними позициями,
+// при меньшем зазоре колонку нужно перенумеровать
+const MinPositionGap = 1e-9
+
+// BoardColumn колонка доски с задачами в порядке позиций.
+// У пользователя с процессом колонка - состояние State, Status - его категория.
+type BoardColumn struct {
+	Status string `json:"status"`
+	State  string `json:"state,omitempty"`
+	Tasks  []Task `json:"tasks"`
+}
+
+// TaskMove перемещение задачи на доске: в колонку Status (или состояние State процесса)
+// между AfterID (выше) и BeforeID (ниже). Без Status и State задача остается в текущей колонке.
+type TaskMove struct {
+	Status   string  `json:"status"`
+	State    string  `json:"state"`
+	AfterID  *string `json:"after_id"`
+	BeforeID *string `json:"before_id"`
+}
+
+// Validate проверяет перемещение
+func (m *TaskMove) Validate(TaskID string) error {
+	var errs ValidationErrors
+
+	// Пустой сосед равнозначен его отсутствию
+	if m.AfterID != nil && *m.AfterID == "" {
+		m.AfterID = nil
+	}
+	if m.BeforeID != nil && *m.BeforeID == "" {
+		m.BeforeID = nil
+	}
+
+	task := Task{Status: m.Status}
+	errs = errs.Add(task.validateStatus())
+
+	m.State = strings.TrimSpace(m.State)
+	switch {
+	case m.State != "" && m.Status != "":
+		errs = errs.Add(newFieldError("state", CodeInvalidValue, "status and state cannot be combined"))
+	case len(m.State) > 50:
+		errs = errs.Add(newFieldError("state", CodeTooLong, "state cannot exceed 50 characters"))
+	}
+
+	for _, neighbor := range []struct {
+		field string
+		value *string
+	}{{"after_id", m.AfterID}, {"before_id", m.BeforeID}} {
+		if neighbor.value == nil {
+			continue
+		}
+		if err := validateOptionalUUID(neighbor.value, neighbor.field); err != nil {
+			errs = errs.Add(err)
+		} else if *neighbor.value == TaskID {
+			errs = errs.Add(newFieldError(neighbor.field, CodeInvalidValue, neighbor.field+" cannot reference the moved task"))
+		}
+	}
+
+	if m.AfterID != nil && m.BeforeID != nil && *m.AfterID == *m.BeforeID {
+		errs = errs.Add(newFieldError("before_id", CodeInvalidValue, "after_id and before_id must differ"))
+	}
+
+	return errs.Err()
+}
+
+// PositionBetween возвращает позицию между соседями after (выше) и before (ниже).
+// nil означает отсутствие соседа с этой стороны. ok = false, если места между соседями
+// не осталось и колонку нужно перенумеровать.
+func PositionBetween(after, before *float64) (position float64, ok bool) {
+	switch {
+	case after == nil && before == nil:
+		return 1, true
+	case after == nil:
+		return *before - 1, true
+	case before == nil:
+		return *after + 1, true
+	}
+
+	if *before-*after < MinPositionGap {
+		return 0, false
+	}
+
+	position = *after + (*before-*after)/2
+	if position <= *after || position >= *before || math.IsNaN(position) {
+		return 0, false
+	}
+
+	return position, true
+}
@@ -0,0 +1,90 @@
+package models
+
+import "fmt"
+
+const MaxBulkTasks = 200
+
+// Действия массовой операции над задачами
+const (
+	BulkActionComplete    = "complete"
+	BulkActionReopen      = "reopen"
+	BulkActionDelete      = "delete"
+	BulkActionSetPriority = "set_priority"
+	BulkActionSetDueDate  = "set_due_date"
+	BulkActionMoveProject = "move_to_project"
+)
+
+var validBulkActions = map[string]bool{
+	BulkActionComplete:    true,
+	BulkActionReopen:      true,
+	BulkActionDelete:      true,
+	BulkActionSetPriority: true,
+	BulkActionSetDueDate:  true,
+	BulkActionMoveProject: true,
+}
+
+// BulkTaskRequest массовая операция над задачами.
+// Priority, DueDate и ProjectID используются соответствующими действиями,
+// пустые due_date и project_id снимают срок и проект.
+type BulkTaskRequest struct {
+	TaskIDs   []string `json:"task_ids"`
+	Action    string   `json:"action"`
+	Priority  string   `json:"priority,omitempty"`
+	DueDate   *string  `json:"due_date,omitempty"`
+	ProjectID *string  `json:"project_id,omitempty"`
+}
+
+// BulkTaskResult результат операции для одной задачи
+type BulkTaskResult struct {
+	TaskID string `json:"task_id"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Validate проверяет запрос и убирает повторяющиеся ID
+func (b *BulkTaskRequest) Validate() error {
+	if !validBulkActions[b.Action] {
+		return newFieldError("action", CodeInvalidEnum, "action must be one of: complete, reopen, delete, set_priority, set_due_date, move_to_project")
+	}
+
+	if len(b.TaskIDs) == 0 {
+		return newFieldError("task_ids", CodeRequired, "task_ids cannot be empty")
+	}
+
+	seen := make(map[string]bool, len(b.TaskIDs))
+	ids := make([]string, 0, len(b.TaskIDs))
+	for _, id := range b.TaskIDs {
+		if !isUUID(id) {
+			return newFieldError("task_ids", CodeInvalidFormat, fmt.Sprintf("invalid task id: %s", id))
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	b.TaskIDs = ids
+
+	if len(b.TaskIDs) > MaxBulkTasks {
+		return newFieldError("task_ids", CodeTooMany, "task_ids cannot contain more than 200 tasks")
+	}
+
+	switch b.Action {
+	case BulkActionSetPriority:
+		task := Task{Priority: b.Priority}
+		if err := task.validatePriority(); err != nil {
+			return err
+		}
+	case BulkActionSetDueDate:
+		// Новый срок, как и при создании задачи, не может быть в прошлом
+		task := Task{DueDate: b.DueDate}
+		if err := task.validateDueDate(); err != nil {
+			return err
+		}
+	case BulkActionMoveProject:
+		if err := validateOptionalUUID(b.ProjectID, "project_id"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCommentsLimit = 50
+	MaxCommentsLimit     = 200
+)
+
+type Comment struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	UserID    string    `json:"user_id"`
+	Login     string    `json:"login"`
+	Body      string    `json:"body"`
+	Edited    bool      `json:"edited"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c *Comment) Validate() error {
+	c.Body = strings.TrimSpace(c.Body)
+
+	if c.Body == "" {
+		return errors.New("body cannot be empty")
+	}
+
+	if len(c.Body) > 5000 {
+		return errors.New("body cannot exceed 5000 characters")
+	}
+
+	return nil
+}
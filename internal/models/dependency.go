@@ -0,0 +1,27 @@
+package models
+
+// TaskDependencies блокирующие задачи и задачи, которые ждут текущую
+type TaskDependencies struct {
+	BlockedBy []Task `json:"blocked_by"`
+	Blocks    []Task `json:"blocks"`
+}
+
+// TaskDependencyRequest добавление блокирующей задачи
+type TaskDependencyRequest struct {
+	BlockerID string `json:"blocker_id"`
+}
+
+// Validate проверяет ссылку на блокирующую задачу для задачи TaskID
+func (d *TaskDependencyRequest) Validate(TaskID string) error {
+	if d.BlockerID == "" {
+		return newFieldError("blocker_id", CodeRequired, "blocker_id is required")
+	}
+	if err := validateOptionalUUID(&d.BlockerID, "blocker_id"); err != nil {
+		return err
+	}
+	if d.BlockerID == TaskID {
+		return newFieldError("blocker_id", CodeInvalidValue, "task cannot block itself")
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// Области учета неудачных попыток входа
+const (
+	ThrottleScopeLogin = "login"
+	ThrottleScopeIP    = "ip"
+)
+
+// LoginThrottlePolicy параметры защиты входа от перебора паролей
+type LoginThrottlePolicy struct {
+	MaxLoginFailures int           // неудач подряд по логину до блокировки (0 - без блокировки)
+	MaxIPFailures    int           // неудач подряд с одного адреса до блокировки (0 - без блокировки)
+	BackoffBase      time.Duration // задержка после первой неудачи, дальше удваивается
+	BackoffMax       time.Duration // предел задержки до блокировки
+	Lockout          time.Duration // длительность блокировки
+	Window           time.Duration // неудачи старше окна забываются
+	AttemptHold      time.Duration // сколько попытка занимает логин, пока проверяется пароль или код
+	IPAttemptHold    time.Duration // то же для адреса, короче: за одним адресом могут входить разные пользователи
+}
+
+// LoginReservation до какого времени попытка заняла каждую область
+type LoginReservation map[string]time.Time
+
+// LoginThrottle счетчик неудачных попыток входа одной области (логина или адреса)
+type LoginThrottle struct {
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  time.Time // нулевое значение - попытки не ограничены
+}
+
+// MaxFailures порог блокировки для области scope
+func (p LoginThrottlePolicy) MaxFailures(scope string) int {
+	if scope == ThrottleScopeIP {
+		return p.MaxIPFailures
+	}
+	return p.MaxLoginFailures
+}
+
+// Hold сколько попытка занимает область scope
+func (p LoginThrottlePolicy) Hold(scope string) time.Duration {
+	if scope == ThrottleScopeIP {
+		return p.IPAttemptHold
+	}
+	return p.AttemptHold
+}
+
+// Delay задержка перед следующей попыткой после failures неудач подряд
+// и признак блокировки (порог maxFailures достигнут)
+func (p LoginThrottlePolicy) Delay(failures int, maxFailures int) (time.Duration, bool) {
+	if failures <= 0 {
+		return 0, false
+	}
+	if maxFailures > 0 && failures >= maxFailures {
+		return p.Lockout, true
+	}
+
+	delay := p.BackoffBase
+	for i := 1; i < failures && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > p.BackoffMax {
+		delay = p.BackoffMax
+	}
+
+	return delay, false
+}
+
+// Wait сколько еще ждать до следующей попытки
+func (t LoginThrottle) Wait(now time.Time) time.Duration {
+	if t.BlockedUntil.After(now) {
+		return t.BlockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// Reserve занимает область scope на время проверки попытки, чтобы параллельные попытки
+// не обошли задержку. false - область занята или заблокирована.
+func (t *LoginThrottle) Reserve(now time.Time, p LoginThrottlePolicy, scope string) bool {
+	if t.Wait(now) > 0 {
+		return false
+	}
+
+	t.BlockedUntil = now.Add(p.Hold(scope))
+	return true
+}
+
+// Release освобождает область после удачной попытки, если она все еще занята броней reserved:
+// задержку или блокировку, назначенную с тех пор другой попыткой, не снимает. Счетчик неудач сохраняется.
+func (t *LoginThrottle) Release(reserved time.Time) {
+	if t.BlockedUntil.Equal(reserved) {
+		t.BlockedUntil = time.Time{}
+	}
+}
+
+// Fail учитывает неудачную попытку области scope: назначает задержку вместо брони.
+// Серия обнуляется по истечении окна. Возвращает признак блокировки.
+func (t *LoginThrottle) Fail(now time.Time, p LoginThrottlePolicy, scope string) bool {
+	if now.Sub(t.LastFailureAt) > p.Window {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = now
+
+	delay, locked := p.Delay(t.Failures, p.MaxFailures(scope))
+	t.BlockedUntil = now.Add(delay)
+	return locked
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// MergePatch применяет JSON Merge Patch (RFC 7396) к документу target.
+// null в патче удаляет поле, объекты сливаются рекурсивно, остальные значения заменяются.
+func MergePatch(target []byte, patch []byte) ([]byte, error) {
+	var targetValue, patchValue interface{}
+
+	if len(target) > 0 {
+		if err := json.Unmarshal(target, &targetValue); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, errors.New("patch must be valid JSON")
+	}
+
+	return json.Marshal(mergePatchValue(targetValue, patchValue))
+}
+
+func mergePatchValue(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatchValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
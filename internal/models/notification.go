@@ -2,8 +2,15 @@ package models
 
 import "time"
 
+// Типы событий уведомлений
+const (
+	NotificationTypeReminder = "reminder"
+	NotificationTypeComment  = "comment"
+)
+
 type Notification struct {
 	ID         string    `json:"id"`
+	Type       string    `json:"type"`
 	Task_id    string    `json:"task_id"`
 	User_id    string    `json:"user_id"`
 	Email      string    `json:"email"`
@@ -12,6 +19,7 @@ type Notification struct {
 	Status     string    `json:"status"`
 	Due_date   *string   `json:"due_date"`
 	Priority   string    `json:"priority"`
+	Occurrence int       `json:"occurrence"`
 	Created_at time.Time `json:"created_at"`
 	Sent_at    time.Time `json:"sent_at"`
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+const DefaultProjectColor = "#4a90d9"
+
+type Project struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	Name           string    `json:"name"`
+	Color          string    `json:"color"`
+	Archived       bool      `json:"archived"`
+	TasksTotal     int       `json:"tasks_total"`
+	TasksCompleted int       `json:"tasks_completed"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (p *Project) Validate() error {
+	if p.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+
+	if len(p.Name) > 100 {
+		return errors.New("name cannot exceed 100 characters")
+	}
+
+	if p.Color == "" {
+		p.Color = DefaultProjectColor
+	}
+
+	matched, err := regexp.MatchString(`^#[0-9a-fA-F]{6}$`, p.Color)
+	if err != nil || !matched {
+		return errors.New("color must be in format #RRGGBB")
+	}
+
+	return nil
+}
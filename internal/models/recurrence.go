@@ -0,0 +1,166 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Recurrence правило повторения задачи
+type Recurrence struct {
+	Freq     string  `json:"freq"`
+	Interval int     `json:"interval,omitempty"`
+	Weekdays []int   `json:"weekdays,omitempty"`
+	MonthDay int     `json:"month_day,omitempty"`
+	Until    *string `json:"until,omitempty"`
+	Count    *int    `json:"count,omitempty"`
+}
+
+func (r *Recurrence) Validate() error {
+	switch r.Freq {
+	case "daily", "weekly", "monthly":
+	default:
+		return errors.New("recurrence freq must be one of: daily, weekly, monthly")
+	}
+
+	if r.Interval < 0 || r.Interval > 365 {
+		return errors.New("recurrence interval must be between 1 and 365 (0 or omitted means 1)")
+	}
+
+	if len(r.Weekdays) > 0 && r.Freq != "weekly" {
+		return errors.New("recurrence weekdays are allowed only for weekly freq")
+	}
+	for _, weekday := range r.Weekdays {
+		if weekday < 0 || weekday > 6 {
+			return errors.New("recurrence weekdays must be between 0 (sunday) and 6 (saturday)")
+		}
+	}
+
+	if r.MonthDay != 0 && r.Freq != "monthly" {
+		return errors.New("recurrence month_day is allowed only for monthly freq")
+	}
+	if r.MonthDay < 0 || r.MonthDay > 31 {
+		return errors.New("recurrence month_day must be between 1 and 31 (0 or omitted means the day of due_date)")
+	}
+
+	if r.Until != nil {
+		if _, err := time.Parse("2006-01-02", *r.Until); err != nil {
+			return errors.New("recurrence until must be in format YYYY-MM-DD")
+		}
+	}
+
+	if r.Count != nil && *r.Count < 1 {
+		return errors.New("recurrence count must be positive")
+	}
+
+	return nil
+}
+
+// AnchorMonthDay закрепляет в ежемесячном правиле без month_day день срока dueDate (RFC 3339).
+// Иначе после короткого месяца серия сдвинулась бы: 31 янв -> 28 фев -> 28 мар.
+func (r *Recurrence) AnchorMonthDay(dueDate *string) {
+	if r == nil || r.Freq != "monthly" || r.MonthDay != 0 || dueDate == nil {
+		return
+	}
+
+	if due, err := time.Parse(time.RFC3339, *dueDate); err == nil {
+		r.MonthDay = due.Day()
+	}
+}
+
+// Next возвращает срок следующего повторения после from.
+// occurrence - порядковый номер текущего повторения (с 1).
+// Если правило исчерпано по count или until, возвращает false.
+func (r *Recurrence) Next(from time.Time, occurrence int) (time.Time, bool) {
+	if r.Count != nil && occurrence >= *r.Count {
+		return time.Time{}, false
+	}
+
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	var next time.Time
+	switch r.Freq {
+	case "daily":
+		next = from.AddDate(0, 0, interval)
+	case "weekly":
+		next = r.nextWeekly(from, interval)
+	case "monthly":
+		next = r.nextMonthly(from, interval)
+	default:
+		return time.Time{}, false
+	}
+
+	if r.Until != nil {
+		// until включает весь указанный день
+		until, err := time.ParseInLocation("2006-01-02", *r.Until, from.Location())
+		if err != nil || !next.Before(until.AddDate(0, 0, 1)) {
+			return time.Time{}, false
+		}
+	}
+
+	return next, true
+}
+
+// nextWeekly ищет ближайший подходящий день недели в неделях, кратных interval
+func (r *Recurrence) nextWeekly(from time.Time, interval int) time.Time {
+	if len(r.Weekdays) == 0 {
+		return from.AddDate(0, 0, 7*interval)
+	}
+
+	days := make(map[time.Weekday]bool, len(r.Weekdays))
+	for _, weekday := range r.Weekdays {
+		days[time.Weekday(weekday)] = true
+	}
+
+	// Недели считаем с понедельника
+	weekStart := from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+
+	for i := 1; i <= 7*interval+7; i++ {
+		candidate := from.AddDate(0, 0, i)
+		week := daysBetween(weekStart, candidate) / 7
+		if week%interval == 0 && days[candidate.Weekday()] {
+			return candidate
+		}
+	}
+
+	return from.AddDate(0, 0, 7*interval)
+}
+
+// nextMonthly возвращает день month_day (или день from) в следующем подходящем месяце.
+// Без month_day день берется из from, поэтому правило закрепляется при создании серии.
+func (r *Recurrence) nextMonthly(from time.Time, interval int) time.Time {
+	day := r.MonthDay
+	if day == 0 {
+		day = from.Day()
+	}
+
+	// Если в текущем месяце нужный день ещё не наступил, берём его
+	candidate := dateInMonth(from, 0, day)
+	if candidate.After(from) {
+		return candidate
+	}
+
+	return dateInMonth(from, interval, day)
+}
+
+// dateInMonth возвращает день day месяца, отстоящего от from на months,
+// ограничивая его последним днем месяца
+func dateInMonth(from time.Time, months int, day int) time.Time {
+	first := time.Date(from.Year(), from.Month()+time.Month(months), 1,
+		from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+func daysBetween(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
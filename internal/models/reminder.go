@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultReminderOffset напоминание за сутки до срока, создается, когда у задачи появляется срок
+const DefaultReminderOffset = 24 * 60
+
+type Reminder struct {
+	ID            string    `json:"id"`
+	TaskID        string    `json:"task_id"`
+	OffsetMinutes *int      `json:"offset_minutes"`
+	RemindAt      *string   `json:"remind_at"`
+	FireAt        *string   `json:"fire_at"`
+	SentAt        *string   `json:"sent_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate проверяет, что задано ровно одно из: смещение до срока или точное время
+func (r *Reminder) Validate() error {
+	hasOffset := r.OffsetMinutes != nil
+	hasRemindAt := r.RemindAt != nil && *r.RemindAt != ""
+
+	if hasOffset == hasRemindAt {
+		return errors.New("either offset_minutes or remind_at must be set")
+	}
+
+	if hasOffset && (*r.OffsetMinutes < 0 || *r.OffsetMinutes > 365*24*60) {
+		return errors.New("offset_minutes must be between 0 and 525600")
+	}
+
+	if hasRemindAt {
+		if _, err := time.Parse(time.RFC3339, *r.RemindAt); err != nil {
+			return errors.New("remind_at must be in format RFC 3339")
+		}
+	}
+
+	return nil
+}
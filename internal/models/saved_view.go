@@ -0,0 +1,88 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const MaxSavedViews = 50
+
+// SavedViewFilters сохраненный фильтр списка задач, поля совпадают с параметрами GET /api/tasks.
+// Даты могут быть относительными (today+7), они пересчитываются при каждом выполнении.
+type SavedViewFilters struct {
+	Project         string   `json:"project,omitempty"`
+	IncludeArchived bool     `json:"include_archived,omitempty"`
+	Statuses        []string `json:"status,omitempty"`
+	Priorities      []string `json:"priority,omitempty"`
+	TagsAny         []string `json:"tags_any,omitempty"`
+	TagsAll         []string `json:"tags_all,omitempty"`
+	DueFrom         *string  `json:"due_from,omitempty"`
+	DueTo           *string  `json:"due_to,omitempty"`
+	Overdue         bool     `json:"overdue,omitempty"`
+	Query           string   `json:"q,omitempty"`
+	Sort            string   `json:"sort,omitempty"`
+	Order           string   `json:"order,omitempty"`
+}
+
+// SavedView именованный фильтр пользователя
+type SavedView struct {
+	ID        string           `json:"id"`
+	UserID    string           `json:"user_id"`
+	Name      string           `json:"name"`
+	Filters   SavedViewFilters `json:"filters"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// TaskFilter строит фильтр списка задач. Даты копируются, чтобы
+// разрешение относительных дат не меняло сохраненный фильтр.
+func (f SavedViewFilters) TaskFilter(loc *time.Location) *TaskFilter {
+	filter := &TaskFilter{
+		Project:         f.Project,
+		IncludeArchived: f.IncludeArchived,
+		Statuses:        f.Statuses,
+		Priorities:      f.Priorities,
+		TagsAny:         f.TagsAny,
+		TagsAll:         f.TagsAll,
+		Overdue:         f.Overdue,
+		Query:           f.Query,
+		Sort:            f.Sort,
+		Order:           f.Order,
+		Location:        loc,
+	}
+
+	if f.DueFrom != nil {
+		dueFrom := *f.DueFrom
+		filter.DueFrom = &dueFrom
+	}
+	if f.DueTo != nil {
+		dueTo := *f.DueTo
+		filter.DueTo = &dueTo
+	}
+
+	return filter
+}
+
+// Validate проверяет название и фильтр представления
+func (v *SavedView) Validate() error {
+	var errs ValidationErrors
+
+	v.Name = strings.TrimSpace(v.Name)
+	switch {
+	case v.Name == "":
+		errs = errs.Add(newFieldError("name", CodeRequired, "name cannot be empty"))
+	case len(v.Name) > 100:
+		errs = errs.Add(newFieldError("name", CodeTooLong, "name cannot exceed 100 characters"))
+	}
+
+	filter := v.Filters.TaskFilter(time.UTC)
+	if err := filter.Validate(); err != nil {
+		errs = errs.Add(newFieldError("filters", CodeInvalidValue, err.Error()))
+	} else {
+		// Теги храним в нормализованном виде
+		v.Filters.TagsAny = filter.TagsAny
+		v.Filters.TagsAll = filter.TagsAll
+	}
+
+	return errs.Err()
+}
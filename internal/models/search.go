@@ -0,0 +1,53 @@
+package models
+
+import "strings"
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxSearchQuery     = 200
+)
+
+// SearchRequest параметры полнотекстового поиска задач
+type SearchRequest struct {
+	Query  string
+	Limit  int
+	Cursor string
+}
+
+// SearchResult найденная задача с релевантностью и подсвеченными фрагментами.
+// Фрагменты - HTML, совпадения обернуты в <mark>, остальной текст экранирован.
+type SearchResult struct {
+	Task           Task    `json:"task"`
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// Validate проверяет запрос и проставляет значения по умолчанию
+func (s *SearchRequest) Validate() error {
+	var errs ValidationErrors
+
+	s.Query = strings.TrimSpace(s.Query)
+	switch {
+	case s.Query == "":
+		errs = errs.Add(newFieldError("q", CodeRequired, "q is required"))
+	case len(s.Query) > MaxSearchQuery:
+		errs = errs.Add(newFieldError("q", CodeTooLong, "q cannot exceed 200 characters"))
+	}
+
+	if s.Limit == 0 {
+		s.Limit = DefaultSearchLimit
+	}
+	if s.Limit < 0 || s.Limit > MaxSearchLimit {
+		errs = errs.Add(newFieldError("limit", CodeInvalidValue, "limit must be between 1 and 100"))
+	}
+
+	if s.Cursor != "" {
+		if _, err := DecodeTaskCursor(s.Cursor); err != nil {
+			errs = errs.Add(newFieldError("cursor", CodeInvalidFormat, err.Error()))
+		}
+	}
+
+	return errs.Err()
+}
@@ -0,0 +1,44 @@
+package models
+
+import "strings"
+
+const (
+	MaxTagsPerTask = 20
+	MaxTagLength   = 50
+)
+
+type Tag struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	TasksCount int    `json:"tasks_count"`
+}
+
+// NormalizeTags убирает пробелы и повторы в списке тегов.
+// nil остается nil: список тегов не передан.
+func NormalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		if len(tag) > MaxTagLength {
+			return nil, newFieldError("tags", CodeTooLong, "tag cannot exceed 50 characters")
+		}
+
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	if len(result) > MaxTagsPerTask {
+		return nil, newFieldError("tags", CodeTooMany, "task cannot have more than 20 tags")
+	}
+
+	return result, nil
+}
@@ -1,62 +1,81 @@
 package models
 
 import (
-	"errors"
 	"regexp"
 	"time"
 )
 
 type Task struct {
-	ID                 string    `json:"id"`
-	UserID             string    `json:"user_id"`
-	Title              string    `json:"title"`
-	Description        string    `json:"description"`
-	Status             string    `json:"status"`
-	Priority           string    `json:"priority"`
-	DueDate            *string   `json:"due_date"`
-	Notified           bool      `json:"notified"`
-	NotificationSentAt time.Time `json:"notification_sent_at"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                 string      `json:"id"`
+	UserID             string      `json:"user_id"`
+	Title              string      `json:"title"`
+	Description        string      `json:"description"`
+	Status             string      `json:"status"`
+	Priority           string      `json:"priority"`
+	DueDate            *string     `json:"due_date"`
+	ParentID           *string     `json:"parent_id"`
+	ProjectID          *string     `json:"project_id"`
+	Tags               []string    `json:"tags"`
+	State              *string     `json:"state"`
+	SubtasksTotal      int         `json:"subtasks_total"`
+	SubtasksCompleted  int         `json:"subtasks_completed"`
+	Progress           *int        `json:"progress,omitempty"`
+	Overdue            bool        `json:"overdue"`
+	Blocked            bool        `json:"blocked"`
+	Recurrence         *Recurrence `json:"recurrence"`
+	SeriesID           *string     `json:"series_id"`
+	Occurrence         int         `json:"occurrence"`
+	Role               string      `json:"role"`
+	Notified           bool        `json:"notified"`
+	NotificationSentAt time.Time   `json:"notification_sent_at"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+	DeletedAt          *string     `json:"deleted_at,omitempty"`
+	Version            int         `json:"version"`
+	Position           float64     `json:"position"`
 }
 
+// Validate проверяет задачу перед созданием и возвращает ошибки всех неверных полей
 func (t *Task) Validate() error {
-	if err := t.validateId(); err != nil {
-		return err
-	}
-
-	if err := t.validateUserId(); err != nil {
-		return err
-	}
-
-	if err := t.validateTitle(); err != nil {
-		return err
-	}
-
-	if err := t.validatePriority(); err != nil {
-		return err
-	}
+	return t.validate(true)
+}
 
-	if err := t.validateDueDate(); err != nil {
-		return err
-	}
+// ValidateUpdate проверяет задачу перед изменением.
+// Срок в прошлом допустим: сохраняемая задача могла уже просрочиться.
+func (t *Task) ValidateUpdate() error {
+	return t.validate(false)
+}
 
-	if err := t.validateStatus(); err != nil {
-		return err
+func (t *Task) validate(create bool) error {
+	var errs ValidationErrors
+
+	errs = errs.Add(t.validateId())
+	errs = errs.Add(t.validateUserId())
+	errs = errs.Add(t.validateTitle())
+	errs = errs.Add(t.validateDescription())
+	errs = errs.Add(t.validatePriority())
+	errs = errs.Add(t.validateStatus())
+	errs = errs.Add(t.validateRecurrence())
+	errs = errs.Add(validateOptionalUUID(t.ParentID, "parent_id"))
+	errs = errs.Add(validateOptionalUUID(t.ProjectID, "project_id"))
+
+	if create {
+		errs = errs.Add(t.validateDueDate())
+	} else {
+		errs = errs.Add(t.validateDueDateFormat())
 	}
 
-	return nil
+	return errs.Err()
 }
 
-// validateId проверяем Id задачи
+// validateId проверяет ID задачи
 func (t *Task) validateId() error {
 	if t.ID == "" {
 		return nil
 	}
 
-	matched, err := regexp.MatchString(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`, t.ID)
-	if err != nil || !matched {
-		return errors.New("Invalid ID")
+	if !isUUID(t.ID) {
+		return newFieldError("id", CodeInvalidFormat, "Invalid ID")
 	}
 
 	return nil
@@ -68,9 +87,8 @@ func (t *Task) validateUserId() error {
 		return nil
 	}
 
-	matched, err := regexp.MatchString(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`, t.UserID)
-	if err != nil || !matched {
-		return errors.New("Invalid User_id")
+	if !isUUID(t.UserID) {
+		return newFieldError("user_id", CodeInvalidFormat, "Invalid User_id")
 	}
 
 	return nil
@@ -79,11 +97,20 @@ func (t *Task) validateUserId() error {
 // validateTitle проверяет заголовок задачи
 func (t *Task) validateTitle() error {
 	if t.Title == "" {
-		return errors.New("title cannot be empty")
+		return newFieldError("title", CodeRequired, "title cannot be empty")
 	}
 
 	if len(t.Title) > 200 {
-		return errors.New("title cannot exceed 200 characters")
+		return newFieldError("title", CodeTooLong, "title cannot exceed 200 characters")
+	}
+
+	return nil
+}
+
+// validateDescription проверяет длину описания задачи
+func (t *Task) validateDescription() error {
+	if len(t.Description) > 10000 {
+		return newFieldError("description", CodeTooLong, "description cannot exceed 10000 characters")
 	}
 
 	return nil
@@ -98,7 +125,7 @@ func (t *Task) validatePriority() error {
 	}
 
 	if !validPriorities[t.Priority] {
-		return errors.New("priority must be one of: low, medium, high")
+		return newFieldError("priority", CodeInvalidEnum, "priority must be one of: low, medium, high")
 	}
 
 	return nil
@@ -115,29 +142,94 @@ func (t *Task) validateDueDate() error {
 		return nil // пустая строка - тоже допустима
 	}
 
-	// Проверяем формат даты (YYYY-MM-DD)
-	matched, err := regexp.MatchString(`^\d{4}-\d{2}-\d{2}$`, dateStr)
-	if err != nil || !matched {
-		return errors.New("due_date must be in format YYYY-MM-DD")
+	if err := t.validateDueDateFormat(); err != nil {
+		return err
 	}
 
-	// Парсим дату
-	due, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
-		return errors.New("invalid due_date format")
+	// Срок без времени проверяем с точностью до дня
+	if matched, _ := regexp.MatchString(`^\d{4}-\d{2}-\d{2}$`, dateStr); matched {
+		due, _ := time.Parse("2006-01-02", dateStr)
+
+		today := time.Now().Truncate(24 * time.Hour)
+		if due.Before(today) {
+			return newFieldError("due_date", CodeInPast, "due_date cannot be in the past")
+		}
+
+		return nil
+	}
+
+	due, _ := time.Parse(time.RFC3339, dateStr)
+	if due.Before(time.Now()) {
+		return newFieldError("due_date", CodeInPast, "due_date cannot be in the past")
 	}
 
-	// Проверяем, что дата не в прошлом
-	today := time.Now().Truncate(24 * time.Hour)
-	due = due.Truncate(24 * time.Hour)
+	return nil
+}
 
-	if due.Before(today) {
-		return errors.New("due_date cannot be in the past")
+// validateDueDateFormat проверяет формат даты выполнения: YYYY-MM-DD или RFC 3339
+func (t *Task) validateDueDateFormat() error {
+	if t.DueDate == nil || *t.DueDate == "" {
+		return nil
+	}
+
+	if _, err := time.Parse("2006-01-02", *t.DueDate); err == nil {
+		return nil
+	}
+
+	if _, err := time.Parse(time.RFC3339, *t.DueDate); err != nil {
+		return newFieldError("due_date", CodeInvalidFormat, "due_date must be in format YYYY-MM-DD or RFC 3339")
+	}
+
+	return nil
+}
+
+// validateRecurrence проверяет правило повторения; повторяющейся задаче нужен срок
+func (t *Task) validateRecurrence() error {
+	if t.Recurrence == nil {
+		return nil
+	}
+
+	if err := t.Recurrence.Validate(); err != nil {
+		return newFieldError("recurrence", CodeInvalidValue, err.Error())
+	}
+
+	if t.DueDate == nil || *t.DueDate == "" {
+		return newFieldError("due_date", CodeRequired, "due_date is required for a recurring task")
 	}
 
 	return nil
 }
 
+// NormalizeDueDate приводит срок выполнения к RFC 3339 в часовом поясе пользователя.
+// Принимает дату (YYYY-MM-DD, срок - конец дня), локальное время
+// (YYYY-MM-DDTHH:MM) или полную метку времени RFC 3339.
+func NormalizeDueDate(dueDate *string, loc *time.Location) (*string, error) {
+	if dueDate == nil || *dueDate == "" {
+		return nil, nil
+	}
+
+	var (
+		due time.Time
+		err error
+	)
+
+	switch value := *dueDate; {
+	case len(value) == len("2006-01-02"):
+		due, err = time.ParseInLocation("2006-01-02", value, loc)
+		due = due.AddDate(0, 0, 1).Add(-time.Second)
+	case len(value) == len("2006-01-02T15:04"):
+		due, err = time.ParseInLocation("2006-01-02T15:04", value, loc)
+	default:
+		due, err = time.Parse(time.RFC3339, value)
+	}
+	if err != nil {
+		return nil, newFieldError("due_date", CodeInvalidFormat, "due_date must be in format YYYY-MM-DD, YYYY-MM-DDTHH:MM or RFC 3339")
+	}
+
+	normalized := due.In(loc).Format(time.RFC3339)
+	return &normalized, nil
+}
+
 // validateStatus проверяет статус задачи
 func (t *Task) validateStatus() error {
 	if t.Status == "" {
@@ -150,8 +242,23 @@ func (t *Task) validateStatus() error {
 	}
 
 	if !validStatuses[t.Status] {
-		return errors.New("status must be one of: active, completed")
+		return newFieldError("status", CodeInvalidEnum, "status must be one of: active, completed")
 	}
 
 	return nil
 }
+
+// validateOptionalUUID проверяет необязательную ссылку на другую сущность
+func validateOptionalUUID(value *string, field string) error {
+	if value == nil || *value == "" || isUUID(*value) {
+		return nil
+	}
+
+	return newFieldError(field, CodeInvalidFormat, field+" must be a valid UUID")
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func isUUID(value string) bool {
+	return uuidPattern.MatchString(value)
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	DefaultTaskEventsLimit = 50
+	MaxTaskEventsLimit     = 200
+)
+
+// Действия в истории задачи
+const (
+	TaskEventCreated  = "created"
+	TaskEventUpdated  = "updated"
+	TaskEventDeleted  = "deleted"
+	TaskEventRestored = "restored"
+)
+
+// TaskEvent запись истории изменений задачи.
+// Для updated заполнены field, old_value и new_value.
+// UserID пустой, если изменение сделала система.
+type TaskEvent struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	UserID    *string   `json:"user_id"`
+	Login     *string   `json:"login"`
+	Action    string    `json:"action"`
+	Field     *string   `json:"field,omitempty"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTasksLimit = 50
+	MaxTasksLimit     = 200
+)
+
+// TaskFilter параметры выборки списка задач
+type TaskFilter struct {
+	Project         string
+	IncludeArchived bool
+	Statuses        []string
+	Priorities      []string
+	TagsAny         []string
+	TagsAll         []string
+	DueFrom         *string
+	DueTo           *string
+	Overdue         bool
+	Query           string
+	Sort            string
+	Order           string
+	Limit           int
+	Cursor          string
+	Location        *time.Location
+}
+
+// TaskCursor позиция в отсортированном списке задач.
+// Курсор списка задач помнит сортировку, по которой получен ключ.
+type TaskCursor struct {
+	Key   string `json:"k"`
+	ID    string `json:"id"`
+	Sort  string `json:"s,omitempty"`
+	Order string `json:"o,omitempty"`
+}
+
+var validTaskSorts = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"due_date":   true,
+	"priority":   true,
+	"title":      true,
+	"position":   true,
+}
+
+// Validate проверяет фильтр и проставляет значения по умолчанию
+func (f *TaskFilter) Validate() error {
+	if f.Project != "" && f.Project != "none" {
+		task := Task{ID: f.Project}
+		if err := task.validateId(); err != nil {
+			return errors.New("project must be a project ID or none")
+		}
+	}
+
+	for _, status := range f.Statuses {
+		task := Task{Status: status}
+		if err := task.validateStatus(); err != nil {
+			return err
+		}
+	}
+
+	for _, priority := range f.Priorities {
+		task := Task{Priority: priority}
+		if err := task.validatePriority(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if f.TagsAny, err = NormalizeTags(f.TagsAny); err != nil {
+		return err
+	}
+	if f.TagsAll, err = NormalizeTags(f.TagsAll); err != nil {
+		return err
+	}
+
+	if len(f.Query) > 200 {
+		return errors.New("q cannot exceed 200 characters")
+	}
+
+	if f.Location == nil {
+		f.Location = time.UTC
+	}
+
+	if err := resolveFilterDate(f.DueFrom, "due_from", f.Location); err != nil {
+		return err
+	}
+	if err := resolveFilterDate(f.DueTo, "due_to", f.Location); err != nil {
+		return err
+	}
+
+	if f.Sort == "" {
+		f.Sort = "created_at"
+	}
+	if !validTaskSorts[f.Sort] {
+		return errors.New("sort must be one of: created_at, updated_at, due_date, priority, title, position")
+	}
+
+	if f.Order == "" {
+		f.Order = "desc"
+	}
+	if f.Order != "asc" && f.Order != "desc" {
+		return errors.New("order must be one of: asc, desc")
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultTasksLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxTasksLimit {
+		return errors.New("limit must be between 1 and 200")
+	}
+
+	if f.Cursor != "" {
+		cursor, err := DecodeTaskCursor(f.Cursor)
+		if err != nil {
+			return err
+		}
+
+		// Ключ другой сортировки не сравним с текущей
+		if cursor.Sort != f.Sort || cursor.Order != f.Order {
+			return errors.New("cursor does not match sort and order")
+		}
+	}
+
+	return nil
+}
+
+// resolveFilterDate проверяет дату фильтра. Относительная дата today, today+N или today-N
+// (N дней) заменяется на календарную дату в часовом поясе loc.
+func resolveFilterDate(date *string, field string, loc *time.Location) error {
+	if date == nil || *date == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(*date, "today") {
+		days := 0
+		if offset := strings.TrimPrefix(*date, "today"); offset != "" {
+			value, err := strconv.Atoi(offset)
+			if err != nil || (offset[0] != '+' && offset[0] != '-') {
+				return errors.New(field + " must be in format YYYY-MM-DD or today, today+N, today-N")
+			}
+			days = value
+		}
+
+		*date = time.Now().In(loc).AddDate(0, 0, days).Format("2006-01-02")
+		return nil
+	}
+
+	if _, err := time.Parse("2006-01-02", *date); err != nil {
+		return errors.New(field + " must be in format YYYY-MM-DD or today, today+N, today-N")
+	}
+
+	return nil
+}
+
+// EncodeTaskCursor упаковывает позицию в непрозрачную строку
+func EncodeTaskCursor(cursor TaskCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTaskCursor распаковывает позицию из строки курсора
+func DecodeTaskCursor(value string) (TaskCursor, error) {
+	var cursor TaskCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	task := Task{ID: cursor.ID}
+	if err := task.validateId(); err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Роли пользователя в задаче
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// RoleAllows проверяет, что роль role не ниже требуемой required
+func RoleAllows(role string, required string) bool {
+	return roleRanks[role] >= roleRanks[required] && roleRanks[role] > 0
+}
+
+type TaskMember struct {
+	TaskID    string    `json:"task_id"`
+	UserID    string    `json:"user_id"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidateRole проверяет роль приглашенного участника
+func (m *TaskMember) ValidateRole() error {
+	if m.Role != RoleViewer && m.Role != RoleEditor {
+		return errors.New("role must be one of: viewer, editor")
+	}
+	return nil
+}
@@ -7,5 +7,6 @@ type User struct {
 	Login    string    `json:"login"`
 	PassHash string    `json:"-"`
 	Email    string    `json:"email"`
+	Timezone string    `json:"timezone"`
 	CreateAt time.Time `json:"create_at"`
 }
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Коды ошибок валидации полей
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeTooMany       = "too_many"
+	CodeInvalidEnum   = "invalid_enum"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidValue  = "invalid_value"
+	CodeInPast        = "in_past"
+)
+
+// FieldError ошибка валидации одного поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func newFieldError(field string, code string, message string) *FieldError {
+	return &FieldError{Field: field, Code: code, Message: message}
+}
+
+// ValidationErrors набор ошибок валидации, по одной на поле
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add добавляет ошибку; для уже отмеченного поля повторная ошибка не добавляется.
+// Ошибка без поля добавляется с кодом invalid_value.
+func (e ValidationErrors) Add(err error) ValidationErrors {
+	if err == nil {
+		return e
+	}
+
+	var list ValidationErrors
+	if errors.As(err, &list) {
+		for _, fieldErr := range list {
+			e = e.Add(fieldErr)
+		}
+		return e
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		fieldErr = newFieldError("", CodeInvalidValue, err.Error())
+	}
+
+	for _, existing := range e {
+		if existing.Field == fieldErr.Field {
+			return e
+		}
+	}
+
+	return append(e, fieldErr)
+}
+
+// Err возвращает nil, если ошибок нет
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
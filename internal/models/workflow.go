@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MaxWorkflowStates = 20
+
+// Категории состояний процесса; категория определяет статус задачи
+const (
+	StateCategoryActive    = "active"
+	StateCategoryCompleted = "completed"
+)
+
+// WorkflowState состояние пользовательского процесса работы с задачами
+type WorkflowState struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Initial  bool   `json:"initial"`
+	Position int    `json:"position"`
+}
+
+// WorkflowTransition разрешенный переход между состояниями (по именам)
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Workflow процесс пользователя. Пустой процесс означает статусы active/completed.
+type Workflow struct {
+	States      []WorkflowState      `json:"states"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// Validate проверяет процесс, проставляет позиции и начальное состояние,
+// если оно не указано (первое состояние категории active)
+func (w *Workflow) Validate() error {
+	var errs ValidationErrors
+
+	if len(w.States) == 0 {
+		return errs.Add(newFieldError("states", CodeRequired, "workflow must have at least one state"))
+	}
+	if len(w.States) > MaxWorkflowStates {
+		return errs.Add(newFieldError("states", CodeTooMany, "workflow cannot have more than 20 states"))
+	}
+
+	names := make(map[string]bool, len(w.States))
+	initial, completed := -1, false
+	for i := range w.States {
+		state := &w.States[i]
+		state.Name = strings.TrimSpace(state.Name)
+		state.Position = i + 1
+
+		switch {
+		case state.Name == "":
+			errs = errs.Add(newFieldError("states", CodeRequired, "state name cannot be empty"))
+		case len(state.Name) > 50:
+			errs = errs.Add(newFieldError("states", CodeTooLong, "state name cannot exceed 50 characters"))
+		case names[state.Name]:
+			errs = errs.Add(newFieldError("states", CodeInvalidValue, fmt.Sprintf("duplicate state: %s", state.Name)))
+		}
+		names[state.Name] = true
+
+		switch state.Category {
+		case StateCategoryActive:
+		case StateCategoryCompleted:
+			completed = true
+		default:
+			errs = errs.Add(newFieldError("states", CodeInvalidEnum, "state category must be one of: active, completed"))
+		}
+
+		if state.Initial {
+			if initial != -1 {
+				errs = errs.Add(newFieldError("states", CodeInvalidValue, "workflow must have exactly one initial state"))
+			}
+			initial = i
+		}
+	}
+
+	if initial == -1 {
+		for i := range w.States {
+			if w.States[i].Category == StateCategoryActive {
+				w.States[i].Initial = true
+				initial = i
+				break
+			}
+		}
+	}
+	if initial == -1 || w.States[initial].Category != StateCategoryActive {
+		errs = errs.Add(newFieldError("states", CodeInvalidValue, "initial state must have category active"))
+	}
+	if !completed {
+		errs = errs.Add(newFieldError("states", CodeRequired, "workflow must have a state with category completed"))
+	}
+
+	for i := range w.Transitions {
+		transition := &w.Transitions[i]
+		transition.From = strings.TrimSpace(transition.From)
+		transition.To = strings.TrimSpace(transition.To)
+
+		if !names[transition.From] || !names[transition.To] {
+			errs = errs.Add(newFieldError("transitions", CodeInvalidValue,
+				fmt.Sprintf("unknown state in transition %s -> %s", transition.From, transition.To)))
+		} else if transition.From == transition.To {
+			errs = errs.Add(newFieldError("transitions", CodeInvalidValue,
+				fmt.Sprintf("transition to the same state: %s", transition.From)))
+		}
+	}
+
+	return errs.Err()
+}
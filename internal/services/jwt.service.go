@@ -3,6 +3,10 @@ package services
 import (
 	"TaskManager/internal/models"
 	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -10,25 +14,118 @@ import (
 )
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Login  string `json:"login"`
+	UserID    string `json:"user_id"`
+	Login     string `json:"login"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
+// JWTService выпускает и проверяет access токены. Токены подписываются
+// асимметричным ключом (RS256 или EdDSA), поэтому другие сервисы проверяют их
+// по открытым ключам из JWKS без общего секрета.
 type JWTService struct {
-	secretKey string
+	keysDir      string
+	signingKeyID string
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+
+	mu      sync.RWMutex
+	keys    map[string]*jwtKey
+	signing *jwtKey
 }
 
-func NewJWTService(secretKey string) *JWTService {
-	return &JWTService{secretKey: secretKey}
+// NewJWTService загружает набор ключей из каталога keysDir. Подписывает ключ
+// signingKeyID, а если он не задан - последний по имени закрытый ключ.
+// Если каталог пуст, в нем создается ключ Ed25519.
+func NewJWTService(keysDir string, signingKeyID string, accessTTL time.Duration, refreshTTL time.Duration) (*JWTService, error) {
+	s := &JWTService{
+		keysDir:      keysDir,
+		signingKeyID: signingKeyID,
+		accessTTL:    accessTTL,
+		refreshTTL:   refreshTTL,
+	}
+
+	keys, err := loadJWTKeys(keysDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		log.Printf("Каталог ключей JWT %s пуст, создается новый ключ Ed25519", keysDir)
+		if err := generateJWTKey(keysDir, signingKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.ReloadKeys(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ReloadKeys перечитывает набор ключей с диска. Для ротации новый ключ кладется
+// в каталог и становится ключом подписи; старый остается в наборе (можно
+// только открытую часть), пока не истекут подписанные им токены.
+func (s *JWTService) ReloadKeys() error {
+	keys, err := loadJWTKeys(s.keysDir)
+	if err != nil {
+		return err
+	}
+
+	signing, err := signingJWTKey(keys, s.signingKeyID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = keys
+	s.signing = signing
+	return nil
+}
+
+// SigningKeyID kid текущего ключа подписи
+func (s *JWTService) SigningKeyID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.signing.id
+}
+
+// JWKS открытые ключи набора, отсортированные по kid
+func (s *JWTService) JWKS() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
+
+// AccessTTL время жизни access токена
+func (s *JWTService) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// RefreshTTL время жизни refresh токена
+func (s *JWTService) RefreshTTL() time.Duration {
+	return s.refreshTTL
 }
 
-func (s *JWTService) GenerateToken(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Токен на 24 часа
+// GenerateToken выпускает короткоживущий access токен сессии sessionID.
+// ID токена (jti) уникален для каждого токена, сессию определяет sid.
+func (s *JWTService) GenerateToken(user *models.User, sessionID string) (string, error) {
+	expirationTime := time.Now().Add(s.accessTTL)
 
 	claims := &Claims{
-		UserID: user.ID,
-		Login:  user.Login,
+		UserID:    user.ID,
+		Login:     user.Login,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -37,19 +134,34 @@ func (s *JWTService) GenerateToken(user *models.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	s.mu.RLock()
+	signing := s.signing
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(signing.method, claims)
+	token.Header["kid"] = signing.id
+	return token.SignedString(signing.private)
 }
 
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+
+		s.mu.RLock()
+		key, ok := s.keys[kid]
+		s.mu.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("неизвестный ключ подписи: %q", kid)
+		}
+		// Алгоритм задает ключ, а не заголовок токена
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("неожиданный метод подписи")
 		}
-		return []byte(s.secretKey), nil
-	})
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -61,17 +173,3 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
-
-func (s *JWTService) RefreshToken(tokenString string) (string, error) {
-	claims, err := s.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// Создаем новый токен с теми же claims, но новым временем истечения
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims.ExpiresAt = jwt.NewNumericDate(expirationTime)
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
-}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Минимальный размер RSA ключа для RS256
+const minRSAKeyBits = 2048
+
+// jwtKey ключ из набора. У ключа, выведенного из ротации, может остаться
+// только открытая часть: им проверяются ранее выпущенные токены.
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JWK открытый ключ в формате JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet набор открытых ключей для /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk открытая часть ключа в формате JWK
+func (k *jwtKey) jwk() JWK {
+	jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
+
+// loadJWTKeys загружает набор ключей из каталога dir.
+// <kid>.pem - закрытый ключ (PKCS#8 или PKCS#1), <kid>.pub.pem - только открытый ключ.
+func loadJWTKeys(dir string) (map[string]*jwtKey, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога ключей: %v", err)
+	}
+
+	keys := make(map[string]*jwtKey, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".pem")
+		publicOnly := strings.HasSuffix(name, ".pub")
+		kid := strings.TrimSuffix(name, ".pub")
+
+		// Закрытый ключ важнее открытого с тем же kid
+		if existing, ok := keys[kid]; ok && (publicOnly || existing.private != nil) {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения ключа %s: %v", kid, err)
+		}
+
+		key, err := parseJWTKey(kid, data, publicOnly)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора ключа %s: %v", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+// parseJWTKey разбирает PEM с ключом RSA (RS256) или Ed25519 (EdDSA)
+func parseJWTKey(kid string, data []byte, publicOnly bool) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("файл не содержит PEM блок")
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM блока: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &jwtKey{id: kid}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		if publicOnly {
+			return nil, errors.New("файл .pub.pem не должен содержать закрытый ключ")
+		}
+		key.private = signer
+		parsed = signer.Public()
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA ключ короче %d бит", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.public = public
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = public
+	default:
+		return nil, errors.New("поддерживаются только ключи RSA и Ed25519")
+	}
+
+	return key, nil
+}
+
+// signingJWTKey выбирает ключ подписи: заданный kid или последний по имени закрытый ключ
+func signingJWTKey(keys map[string]*jwtKey, kid string) (*jwtKey, error) {
+	if kid != "" {
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("ключ подписи %s не найден", kid)
+		}
+		if key.private == nil {
+			return nil, fmt.Errorf("для ключа подписи %s нет закрытого ключа", kid)
+		}
+		return key, nil
+	}
+
+	ids := make([]string, 0, len(keys))
+	for id, key := range keys {
+		if key.private != nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("в наборе нет закрытого ключа для подписи")
+	}
+
+	sort.Strings(ids)
+	return keys[ids[len(ids)-1]], nil
+}
+
+// generateJWTKey создает в каталоге dir новый ключ Ed25519.
+// Без kid имя ключа - метка времени, чтобы последующие ключи сортировались после него.
+func generateJWTKey(dir string, kid string) error {
+	if kid == "" {
+		kid = time.Now().UTC().Format("20060102-150405")
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("ошибка генерации ключа: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования ключа: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога ключей: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		return fmt.Errorf("ошибка записи ключа: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateRefreshToken создает непрозрачный refresh токен и его хеш для хранения в БД
+func GenerateRefreshToken() (token string, hash string, err error) {
+	return generateOpaqueToken()
+}
+
+// HashRefreshToken хеш refresh токена; сам токен в БД не хранится
+func HashRefreshToken(token string) string {
+	return hashOpaqueToken(token)
+}
+
+// generateOpaqueToken случайный токен (32 байта, base64url) и его хеш
+func generateOpaqueToken() (token string, hash string, err error) {
+	data := make([]byte, 32)
+	if _, err = rand.Read(data); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(data)
+	return token, hashOpaqueToken(token), nil
+}
+
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
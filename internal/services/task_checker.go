@@ -34,31 +34,38 @@ func (tc *TaskChecker) Start() {
 }
 
 func (tc *TaskChecker) checkDueTasks() {
-	log.Println("Проверка запланированных задач...")
+	log.Println("Проверка запланированных напоминаний...")
 
+	// Напоминание срабатывает в заданное время или за offset_minutes до срока.
+	// Пропущенные больше суток назад напоминания не отправляем.
 	query := `
-        SELECT t.id, t.user_id, u.email, t.title, t.description, t.priority, t.due_date
-        FROM tasks t
+        SELECT r.id, t.id, t.user_id, u.email, t.title, t.description, t.priority, t.due_date, t.occurrence
+        FROM reminders r
+		INNER JOIN tasks t ON t.id = r.task_id
 		INNER JOIN users u ON u.id = t.user_id
-        WHERE t.deleted = false 
-          AND t.notified = false 
-          AND t.due_date IS NOT NULL 
-          AND t.due_date <= CURRENT_DATE
-          AND t.due_date >= CURRENT_DATE - INTERVAL '1 day'
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(r.remind_at, t.due_date - make_interval(mins => r.offset_minutes)) AS fire_at
+		) f
+        WHERE r.sent_at IS NULL
+          AND t.deleted = false 
+          AND t.status <> 'completed'
+          AND f.fire_at <= now()
+          AND f.fire_at >= now() - INTERVAL '1 day'
 		  AND email IS NOT NULL
     `
 
 	rows, err := tc.db.Query(query)
 	if err != nil {
-		log.Printf("Ошибка поиска запланированных задач: %v", err)
+		log.Printf("Ошибка поиска запланированных напоминаний: %v", err)
 		return
 	}
 	defer rows.Close()
 
 	var notifications []models.Notification
 	for rows.Next() {
-		var notification models.Notification
+		notification := models.Notification{Type: models.NotificationTypeReminder}
 		err := rows.Scan(
+			&notification.ID,
 			&notification.Task_id,
 			&notification.User_id,
 			&notification.Email,
@@ -66,31 +73,41 @@ func (tc *TaskChecker) checkDueTasks() {
 			&notification.Message,
 			&notification.Priority,
 			&notification.Due_date,
+			&notification.Occurrence,
 		)
 		if err != nil {
-			log.Printf("Ошибка сканирования задачи %s: %v", notification.ID, err)
+			log.Printf("Ошибка сканирования напоминания %s: %v", notification.ID, err)
 			continue
 		}
 		notifications = append(notifications, notification)
 	}
 
 	for _, notification := range notifications {
-		if err := tc.processTask(&notification); err != nil {
-			log.Printf("Ошибка обработки задачи %s: %v", notification.Task_id, err)
+		if err := tc.processReminder(&notification); err != nil {
+			log.Printf("Ошибка обработки напоминания %s: %v", notification.ID, err)
 		}
 	}
-
-	//log.Printf("Обработано %d запланированных задач", len(tasks))
 }
 
-func (tc *TaskChecker) processTask(notification *models.Notification) error {
+func (tc *TaskChecker) processReminder(notification *models.Notification) error {
 	// Отправляем уведомление
 	if err := tc.kafkaProducer.SendNotification(notification); err != nil {
 		return fmt.Errorf("ошибка отпраки уведомления: %v", err)
 	}
 
-	// Обновляем задачу как уведомленную
+	// Отмечаем напоминание отправленным
 	_, err := tc.db.Exec(`
+        UPDATE reminders 
+        SET sent_at = $1 
+        WHERE id = $2`,
+		time.Now(), notification.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления времени отправки напоминания: %v", err)
+	}
+
+	// Сохраняем время последнего уведомления у задачи
+	_, err = tc.db.Exec(`
         UPDATE tasks 
         SET notified = true, notification_sent_at = $1 
         WHERE id = $2`,
@@ -100,6 +117,6 @@ func (tc *TaskChecker) processTask(notification *models.Notification) error {
 		return fmt.Errorf("ошибка обновления времени отправки уведомления у задачи: %v", err)
 	}
 
-	log.Printf("Уведомление отправлено для задачи: %s", notification.Task_id)
+	log.Printf("Напоминание %s отправлено для задачи: %s", notification.ID, notification.Task_id)
 	return nil
 }
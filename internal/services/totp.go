@@ -0,0 +1,154 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) - значения по умолчанию приложений-аутентификаторов
+const (
+	TOTPIssuer = "TaskManager"
+	totpDigits = 6
+	totpPeriod = 30
+	// Допустимое расхождение часов клиента, в шагах
+	totpSkew = 1
+)
+
+// Количество одноразовых кодов восстановления
+const RecoveryCodesCount = 10
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret создает секрет TOTP (160 бит, base32)
+func GenerateTOTPSecret() (string, error) {
+	data := make([]byte, 20)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(data), nil
+}
+
+// TOTPURI ссылка otpauth:// для добавления секрета в приложение-аутентификатор
+func TOTPURI(account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPStep номер 30-секундного шага для момента t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// TOTPCode код для момента t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, TOTPStep(t)), nil
+}
+
+// ValidateTOTP проверяет код с допуском в один шаг и возвращает шаг совпавшего кода.
+// Шаги не новее lastStep отвергаются: один код нельзя использовать дважды.
+func ValidateTOTP(secret string, code string, t time.Time, lastStep int64) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(t)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// IsTOTPCode похож ли ввод на код TOTP, а не на код восстановления
+func IsTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateRecoveryCodes создает коды восстановления вида xxxxx-xxxxx и их хеши для БД
+func GenerateRecoveryCodes() (codes []string, hashes []string, err error) {
+	codes = make([]string, RecoveryCodesCount)
+	hashes = make([]string, RecoveryCodesCount)
+
+	for i := range codes {
+		data := make([]byte, 6)
+		if _, err = rand.Read(data); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(data))
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode хеш кода восстановления; регистр, пробелы и дефисы не важны
+func HashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	return hashOpaqueToken(normalized)
+}
+
+// GenerateLoginChallenge создает токен второго шага входа и его хеш для БД
+func GenerateLoginChallenge() (token string, hash string, err error) {
+	return generateOpaqueToken()
+}
+
+// HashLoginChallenge хеш токена второго шага входа
+func HashLoginChallenge(token string) string {
+	return hashOpaqueToken(token)
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	return totpEncoding.DecodeString(strings.ToUpper(strings.ReplaceAll(secret, " ", "")))
+}
+
+// totpCode HOTP (RFC 4226) для шага step
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// TrashCleaner окончательно удаляет задачи, пролежавшие в корзине дольше retention
+type TrashCleaner struct {
+	db        *sql.DB
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashCleaner(db *sql.DB, retention time.Duration, interval time.Duration) *TrashCleaner {
+	return &TrashCleaner{
+		db:        db,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+func (tc *TrashCleaner) Start() {
+	log.Println("TrashCleaner запущен")
+
+	ticker := time.NewTicker(tc.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tc.purgeExpired()
+	}
+}
+
+func (tc *TrashCleaner) purgeExpired() {
+	// Подзадачи удаляются каскадно вместе с родительской задачей
+	result, err := tc.db.Exec(`
+		DELETE FROM tasks
+		WHERE deleted = true
+			AND COALESCE(deleted_at, updated_at) < $1
+	`, time.Now().Add(-tc.retention))
+	if err != nil {
+		log.Printf("Ошибка очистки корзины: %v", err)
+		return
+	}
+
+	if purged, err := result.RowsAffected(); err == nil && purged > 0 {
+		log.Printf("Из корзины окончательно удалено задач: %d", purged)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/models"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestPositionBetween(t *testing.T) {
+	pos := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name   string
+		after  *float64
+		before *float64
+		want   float64
+		wantOK bool
+	}{
+		{"empty column", nil, nil, 1, true},
+		{"to the top", nil, pos(3), 2, true},
+		{"to the bottom", pos(3), nil, 4, true},
+		{"between", pos(1), pos(2), 1.5, true},
+		{"no gap", pos(1), pos(1), 0, false},
+		{"gap too small", pos(1), pos(1 + models.MinPositionGap/2), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := models.PositionBetween(tt.after, tt.before)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("PositionBetween() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTaskMoveValidation(t *testing.T) {
+	taskID := "123e4567-e89b-12d3-a456-426614174000"
+	other := "123e4567-e89b-12d3-a456-426614174001"
+	invalid := "42"
+	empty := ""
+
+	tests := []struct {
+		name    string
+		move    models.TaskMove
+		wantErr bool
+	}{
+		{"same column", models.TaskMove{}, false},
+		{"to completed", models.TaskMove{Status: "completed", AfterID: &other}, false},
+		{"empty neighbor", models.TaskMove{BeforeID: &empty}, false},
+		{"to workflow state", models.TaskMove{State: "Review", BeforeID: &other}, false},
+		{"invalid status", models.TaskMove{Status: "archived"}, true},
+		{"status with state", models.TaskMove{Status: "completed", State: "Done"}, true},
+		{"invalid neighbor", models.TaskMove{AfterID: &invalid}, true},
+		{"self neighbor", models.TaskMove{BeforeID: &taskID}, true},
+		{"same neighbors", models.TaskMove{AfterID: &other, BeforeID: &other}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.move.Validate(taskID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMoveTaskStateFromOwnerWorkflow(t *testing.T) {
+	const (
+		taskID  = "b3f1c2d4-1111-4a2b-9c3d-000000000001"
+		stateID = "b3f1c2d4-1111-4a2b-9c3d-0000000000a1"
+		editor  = "b3f1c2d4-1111-4a2b-9c3d-0000000000e1"
+	)
+
+	newFake := func() *fakeDB {
+		fake := &fakeDB{}
+		fake.row("THEN 'owner'", "editor")
+		fake.row("SELECT t.status, COALESCE", "active", "active", "To Do")
+		// Процесс владельца знает "Review", у редактора такого состояния нет
+		fake.on("SELECT ws.category", func(args []driver.Value) ([]string, [][]driver.Value, error) {
+			if args[0] == taskID && args[1] == "Review" {
+				return []string{"category"}, [][]driver.Value{{"active"}}, nil
+			}
+			return []string{"category"}, nil, nil
+		})
+		fake.row("AND name = $2 AND category = $3", "active")
+		fake.row("SELECT max(", nil)
+		fake.row("SELECT t.status, t.state_id", "active", stateID, stateID, "active")
+		return fake
+	}
+
+	fake := newFake()
+	db := openFakeDB(t, fake)
+	user, task := editor, taskID
+	if err := controllers.MoveTaskDataBase(db, &user, &task, &models.TaskMove{State: "Review"}, false); err != nil {
+		t.Fatalf("MoveTaskDataBase() error = %v", err)
+	}
+	if calls := fake.called("SELECT t.status, t.state_id"); len(calls) != 1 || calls[0].args[1] != "Review" {
+		t.Errorf("transition calls = %v, want one to Review", calls)
+	}
+	if calls := fake.called("INSERT INTO task_positions"); len(calls) != 1 || calls[0].args[0] != editor {
+		t.Errorf("position saves = %v, want one for the mover", calls)
+	}
+
+	t.Run("state missing in owner workflow", func(t *testing.T) {
+		db := openFakeDB(t, newFake())
+		err := controllers.MoveTaskDataBase(db, &user, &task, &models.TaskMove{State: "Archive"}, false)
+		if !errors.Is(err, controllers.ErrBoardColumnNotFound) {
+			t.Errorf("MoveTaskDataBase() error = %v, want %v", err, controllers.ErrBoardColumnNotFound)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"testing"
+)
+
+func TestBulkTaskRequestValidation(t *testing.T) {
+	id := "123e4567-e89b-12d3-a456-426614174000"
+
+	tests := []struct {
+		name    string
+		request models.BulkTaskRequest
+		wantErr bool
+	}{
+		{"complete", models.BulkTaskRequest{TaskIDs: []string{id}, Action: "complete"}, false},
+		{"set priority", models.BulkTaskRequest{TaskIDs: []string{id}, Action: "set_priority", Priority: "high"}, false},
+		{"unknown action", models.BulkTaskRequest{TaskIDs: []string{id}, Action: "archive"}, true},
+		{"empty ids", models.BulkTaskRequest{Action: "delete"}, true},
+		{"invalid id", models.BulkTaskRequest{TaskIDs: []string{"42"}, Action: "delete"}, true},
+		{"invalid priority", models.BulkTaskRequest{TaskIDs: []string{id}, Action: "set_priority", Priority: "urgent"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBulkTaskRequestDeduplicatesIDs(t *testing.T) {
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	request := models.BulkTaskRequest{TaskIDs: []string{id, id}, Action: "complete"}
+
+	if err := request.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if len(request.TaskIDs) != 1 {
+		t.Errorf("TaskIDs = %v, want one id", request.TaskIDs)
+	}
+}
@@ -0,0 +1,31 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"testing"
+)
+
+func TestTaskDependencyRequestValidation(t *testing.T) {
+	taskID := "123e4567-e89b-12d3-a456-426614174000"
+
+	tests := []struct {
+		name      string
+		blockerID string
+		wantErr   bool
+	}{
+		{"valid", "123e4567-e89b-12d3-a456-426614174001", false},
+		{"empty", "", true},
+		{"invalid", "42", true},
+		{"self", taskID, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := models.TaskDependencyRequest{BlockerID: tt.blockerID}
+			err := request.Validate(taskID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB сценарная БД для проверки контроллеров без PostgreSQL.
+// Запрос сопоставляется с первым правилом, текст которого он содержит.
+// Exec без правила считается успешным, Query без правила - ошибка теста.
+type fakeDB struct {
+	mu    sync.Mutex
+	rules []fakeRule
+	calls []fakeCall
+}
+
+type fakeRule struct {
+	contains string
+	handle   func(args []driver.Value) (columns []string, rows [][]driver.Value, err error)
+}
+
+type fakeCall struct {
+	query string
+	args  []driver.Value
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = map[string]*fakeDB{}
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// openFakeDB открывает *sql.DB поверх сценария
+func openFakeDB(t *testing.T, fake *fakeDB) *sql.DB {
+	t.Helper()
+
+	fakeDBsMu.Lock()
+	fakeDBs[t.Name()] = fake
+	fakeDBsMu.Unlock()
+
+	db, err := sql.Open("fakedb", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBsMu.Lock()
+		delete(fakeDBs, t.Name())
+		fakeDBsMu.Unlock()
+	})
+
+	return db
+}
+
+// on добавляет правило для запросов, содержащих contains
+func (f *fakeDB) on(contains string, handle func(args []driver.Value) ([]string, [][]driver.Value, error)) {
+	f.rules = append(f.rules, fakeRule{contains: contains, handle: handle})
+}
+
+// row правило с одной постоянной строкой результата
+func (f *fakeDB) row(contains string, values ...driver.Value) {
+	columns := make([]string, len(values))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	f.on(contains, func([]driver.Value) ([]string, [][]driver.Value, error) {
+		return columns, [][]driver.Value{values}, nil
+	})
+}
+
+// called возвращает вызовы запросов, содержащих contains
+func (f *fakeDB) called(contains string) []fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []fakeCall
+	for _, call := range f.calls {
+		if strings.Contains(call.query, contains) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (f *fakeDB) run(query string, args []driver.Value, exec bool) ([]string, [][]driver.Value, error) {
+	query = strings.Join(strings.Fields(query), " ")
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{query: query, args: args})
+	var rule *fakeRule
+	for i := range f.rules {
+		if strings.Contains(query, f.rules[i].contains) {
+			rule = &f.rules[i]
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if rule == nil {
+		if exec {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("fakedb: unexpected query: %s", query)
+	}
+
+	return rule.handle(args)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+
+	fake, ok := fakeDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: unknown database %q", name)
+	}
+	return &fakeConn{db: fake}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	_, _, err := c.db.run(query, namedValues(args), true)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	columns, rows, err := c.db.run(query, namedValues(args), false)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+// CheckNamedValue пропускает аргументы без преобразования, как их передал контроллер
+func (c *fakeConn) CheckNamedValue(value *driver.NamedValue) error {
+	if valuer, ok := value.Value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		value.Value = v
+	}
+	return nil
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"TaskManager/internal/controllers"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestReplayIdempotencyKey(t *testing.T) {
+	task := sql.NullString{String: "b3f1c2d4-1111-4a2b-9c3d-000000000001", Valid: true}
+
+	tests := []struct {
+		name       string
+		storedHash string
+		taskID     sql.NullString
+		wantID     string
+		wantErr    error
+	}{
+		{"replay returns the original task", "hash", task, task.String, nil},
+		{"different body is a reused key", "other", task, "", controllers.ErrIdempotencyKeyReused},
+		{"purged task is gone", "hash", sql.NullString{}, "", controllers.ErrIdempotentTaskGone},
+		{"different body wins over purged task", "other", sql.NullString{}, "", controllers.ErrIdempotencyKeyReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := controllers.ReplayIdempotencyKey(tt.storedHash, tt.taskID, "hash")
+			if got != tt.wantID || !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReplayIdempotencyKey() = %q, %v, want %q, %v", got, err, tt.wantID, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindIdempotentTask(t *testing.T) {
+	const taskID = "b3f1c2d4-1111-4a2b-9c3d-000000000001"
+
+	fake := &fakeDB{}
+	fake.on("FROM idempotency_keys", func(args []driver.Value) ([]string, [][]driver.Value, error) {
+		columns := []string{"request_hash", "task_id"}
+		if args[1] == "used" {
+			return columns, [][]driver.Value{{"hash", taskID}}, nil
+		}
+		return columns, nil, nil
+	})
+	db := openFakeDB(t, fake)
+
+	got, err := controllers.FindIdempotentTaskDataBase(db, "user", "used", "hash")
+	if got != taskID || err != nil {
+		t.Errorf("used key = %q, %v, want %q", got, err, taskID)
+	}
+
+	got, err = controllers.FindIdempotentTaskDataBase(db, "user", "fresh", "hash")
+	if got != "" || err != nil {
+		t.Errorf("fresh key = %q, %v, want empty", got, err)
+	}
+
+	if _, err = controllers.FindIdempotentTaskDataBase(db, "user", "used", "other"); !errors.Is(err, controllers.ErrIdempotencyKeyReused) {
+		t.Errorf("reused key error = %v, want %v", err, controllers.ErrIdempotencyKeyReused)
+	}
+
+	if n := len(fake.called("FOR UPDATE")) + len(fake.called("INSERT INTO idempotency_keys")); n != 0 {
+		t.Errorf("lookup must not claim the key, got %d locking queries", n)
+	}
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newTestJWTService(t *testing.T, dir string, kid string, accessTTL time.Duration) *services.JWTService {
+	t.Helper()
+
+	service, err := services.NewJWTService(dir, kid, accessTTL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWTService() error = %v", err)
+	}
+	return service
+}
+
+func writeRSAKey(t *testing.T, dir string, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestJWTServiceSessionClaim(t *testing.T) {
+	service := newTestJWTService(t, t.TempDir(), "", time.Minute)
+	user := &models.User{ID: "123e4567-e89b-12d3-a456-426614174000", Login: "user"}
+
+	first, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	second, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	claims, err := service.ValidateToken(first)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if claims.SessionID != "session-1" || claims.UserID != user.ID {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	other, err := service.ValidateToken(second)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if other.ID == claims.ID {
+		t.Error("each token must have its own jti")
+	}
+}
+
+func TestJWTServiceRejectsExpiredToken(t *testing.T) {
+	service := newTestJWTService(t, t.TempDir(), "", -time.Minute)
+	user := &models.User{ID: "123e4567-e89b-12d3-a456-426614174000", Login: "user"}
+
+	token, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if _, err := service.ValidateToken(token); err == nil {
+		t.Error("expected expired token to be rejected")
+	}
+}
+
+func TestJWTServiceGeneratesKeyForEmptyDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	service := newTestJWTService(t, dir, "first", time.Minute)
+
+	if _, err := os.Stat(filepath.Join(dir, "first.pem")); err != nil {
+		t.Fatalf("expected generated key file: %v", err)
+	}
+
+	jwks := service.JWKS()
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(jwks.Keys))
+	}
+	key := jwks.Keys[0]
+	if key.Kid != "first" || key.Kty != "OKP" || key.Crv != "Ed25519" || key.Alg != "EdDSA" || key.X == "" {
+		t.Errorf("unexpected JWK: %+v", key)
+	}
+}
+
+func TestJWTServiceKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	user := &models.User{ID: "123e4567-e89b-12d3-a456-426614174000", Login: "user"}
+
+	service := newTestJWTService(t, dir, "", time.Minute)
+	oldKid := service.SigningKeyID()
+
+	oldToken, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	// Новый ключ сортируется после старого и становится ключом подписи
+	writeRSAKey(t, dir, "zz-rsa")
+	if err := service.ReloadKeys(); err != nil {
+		t.Fatalf("ReloadKeys() error = %v", err)
+	}
+	if service.SigningKeyID() != "zz-rsa" {
+		t.Fatalf("expected signing key zz-rsa, got %s", service.SigningKeyID())
+	}
+
+	newToken, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := service.ValidateToken(token); err != nil {
+			t.Errorf("ValidateToken() after rotation error = %v", err)
+		}
+	}
+
+	jwks := service.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[1].Kty != "RSA" || jwks.Keys[1].Alg != "RS256" || jwks.Keys[1].N == "" {
+		t.Errorf("unexpected JWKS: %+v", jwks)
+	}
+
+	// Удаленный из набора ключ больше не принимается
+	if err := os.Remove(filepath.Join(dir, oldKid+".pem")); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := service.ReloadKeys(); err != nil {
+		t.Fatalf("ReloadKeys() error = %v", err)
+	}
+	if _, err := service.ValidateToken(oldToken); err == nil {
+		t.Error("expected token of removed key to be rejected")
+	}
+}
+
+func TestJWTServiceRejectsForeignSignature(t *testing.T) {
+	service := newTestJWTService(t, t.TempDir(), "", time.Minute)
+	user := &models.User{ID: "123e4567-e89b-12d3-a456-426614174000", Login: "user"}
+
+	// Токен с тем же kid, подписанный HS256
+	claims := &services.Claims{UserID: user.ID, Login: user.Login, SessionID: "session-1"}
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmacToken.Header["kid"] = service.SigningKeyID()
+	signed, err := hmacToken.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	if _, err := service.ValidateToken(signed); err == nil {
+		t.Error("expected HS256 token to be rejected")
+	}
+
+	// Подпись чужим ключом того же типа
+	token, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	other := newTestJWTService(t, t.TempDir(), "", time.Minute)
+	forged, err := other.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	parts, forgedParts := strings.Split(token, "."), strings.Split(forged, ".")
+	if _, err := service.ValidateToken(parts[0] + "." + parts[1] + "." + forgedParts[2]); err == nil {
+		t.Error("expected token with foreign signature to be rejected")
+	}
+}
+
+func TestJWTServiceUnknownSigningKey(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa")
+
+	if _, err := services.NewJWTService(dir, "missing", time.Minute, time.Hour); err == nil {
+		t.Error("expected error for unknown signing key id")
+	}
+}
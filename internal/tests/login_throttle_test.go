@@ -0,0 +1,274 @@
+package tests
+
+import (
+	"TaskManager/internal/config"
+	"TaskManager/internal/controllers"
+	"TaskManager/internal/handlers"
+	"TaskManager/internal/models"
+	"TaskManager/internal/services"
+	"context"
+	"database/sql/driver"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicyDelay(t *testing.T) {
+	policy := models.LoginThrottlePolicy{
+		MaxLoginFailures: 5,
+		MaxIPFailures:    20,
+		BackoffBase:      time.Second,
+		BackoffMax:       5 * time.Second,
+		Lockout:          15 * time.Minute,
+		Window:           15 * time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+		locked   bool
+	}{
+		{0, 0, false},
+		{1, time.Second, false},
+		{2, 2 * time.Second, false},
+		{3, 4 * time.Second, false},
+		{4, 5 * time.Second, false},
+		{5, 15 * time.Minute, true},
+		{6, 15 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		delay, locked := policy.Delay(tt.failures, policy.MaxFailures(models.ThrottleScopeLogin))
+		if delay != tt.delay || locked != tt.locked {
+			t.Errorf("Delay(%d) = %v, %v; want %v, %v", tt.failures, delay, locked, tt.delay, tt.locked)
+		}
+	}
+}
+
+func TestLoginThrottlePolicyScopes(t *testing.T) {
+	policy := models.LoginThrottlePolicy{
+		MaxLoginFailures: 5,
+		MaxIPFailures:    20,
+		BackoffBase:      time.Second,
+		BackoffMax:       time.Minute,
+		Lockout:          time.Hour,
+	}
+
+	if _, locked := policy.Delay(5, policy.MaxFailures(models.ThrottleScopeIP)); locked {
+		t.Error("ip must not be locked before its own threshold")
+	}
+	if _, locked := policy.Delay(20, policy.MaxFailures(models.ThrottleScopeIP)); !locked {
+		t.Error("ip must be locked at its threshold")
+	}
+
+	// Без порога только задержка, предел которой не превышается
+	delay, locked := policy.Delay(1000, 0)
+	if locked || delay != time.Minute {
+		t.Errorf("Delay without threshold = %v, %v; want %v, false", delay, locked, time.Minute)
+	}
+}
+
+func TestLoginThrottleReserve(t *testing.T) {
+	policy := models.LoginThrottlePolicy{
+		MaxLoginFailures: 3,
+		BackoffBase:      time.Second,
+		BackoffMax:       5 * time.Second,
+		Lockout:          15 * time.Minute,
+		Window:           15 * time.Minute,
+		AttemptHold:      10 * time.Second,
+		IPAttemptHold:    time.Second,
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := models.LoginThrottle{LastFailureAt: now}
+
+	// Параллельная попытка не проходит, пока первая не завершилась
+	if !throttle.Reserve(now, policy, models.ThrottleScopeLogin) {
+		t.Fatal("first attempt must be reserved")
+	}
+	if throttle.Reserve(now, policy, models.ThrottleScopeLogin) {
+		t.Fatal("parallel attempt must be rejected while the first one is in progress")
+	}
+	if wait := throttle.Wait(now); wait != policy.AttemptHold {
+		t.Errorf("Wait() during attempt = %v, want %v", wait, policy.AttemptHold)
+	}
+
+	// Неудача заменяет бронь задержкой
+	if locked := throttle.Fail(now, policy, models.ThrottleScopeLogin); locked {
+		t.Fatal("first failure must not lock")
+	}
+	if throttle.Reserve(now.Add(500*time.Millisecond), policy, models.ThrottleScopeLogin) {
+		t.Error("attempt during backoff must be rejected")
+	}
+	now = now.Add(time.Second)
+	if !throttle.Reserve(now, policy, models.ThrottleScopeLogin) {
+		t.Fatal("attempt after backoff must be reserved")
+	}
+
+	// Верный пароль при включенной 2FA освобождает логин, но не сбрасывает счетчик
+	throttle.Release(now.Add(policy.AttemptHold))
+	if throttle.Failures != 1 {
+		t.Errorf("Failures after release = %d, want 1", throttle.Failures)
+	}
+	if !throttle.Reserve(now, policy, models.ThrottleScopeLogin) {
+		t.Fatal("second factor attempt must be reserved after release")
+	}
+
+	// Неверные коды второго шага продолжают ту же серию и приводят к блокировке
+	if locked := throttle.Fail(now, policy, models.ThrottleScopeLogin); locked {
+		t.Fatal("second failure must not lock")
+	}
+	if wait := throttle.Wait(now); wait != 2*time.Second {
+		t.Errorf("Wait() after second failure = %v, want %v", wait, 2*time.Second)
+	}
+	now = now.Add(2 * time.Second)
+	if locked := throttle.Fail(now, policy, models.ThrottleScopeLogin); !locked {
+		t.Fatal("third failure must lock")
+	}
+	if wait := throttle.Wait(now); wait != policy.Lockout {
+		t.Errorf("Wait() after lockout = %v, want %v", wait, policy.Lockout)
+	}
+
+	// Серия забывается по истечении окна
+	now = now.Add(policy.Lockout + policy.Window)
+	throttle.Fail(now, policy, models.ThrottleScopeLogin)
+	if throttle.Failures != 1 {
+		t.Errorf("Failures after window = %d, want 1", throttle.Failures)
+	}
+}
+
+func TestLoginThrottleReleaseOwnReservation(t *testing.T) {
+	policy := models.LoginThrottlePolicy{
+		MaxLoginFailures: 3,
+		BackoffBase:      time.Minute,
+		BackoffMax:       time.Hour,
+		Lockout:          15 * time.Minute,
+		Window:           15 * time.Minute,
+		AttemptHold:      10 * time.Second,
+		IPAttemptHold:    time.Second,
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Адрес занимается ненадолго, чтобы не задерживать соседей по NAT
+	var ip models.LoginThrottle
+	ip.Reserve(now, policy, models.ThrottleScopeIP)
+	if wait := ip.Wait(now); wait != policy.IPAttemptHold {
+		t.Errorf("ip Wait() during attempt = %v, want %v", wait, policy.IPAttemptHold)
+	}
+
+	// Бронь истекла, другая попытка успела провалиться: задержка не снимается
+	var login models.LoginThrottle
+	login.Reserve(now, policy, models.ThrottleScopeLogin)
+	reserved := login.BlockedUntil
+	later := now.Add(policy.AttemptHold + time.Second)
+	if !login.Reserve(later, policy, models.ThrottleScopeLogin) {
+		t.Fatal("attempt after the hold expired must be reserved")
+	}
+	login.Fail(later, policy, models.ThrottleScopeLogin)
+
+	login.Release(reserved)
+	if wait := login.Wait(later); wait != policy.BackoffBase {
+		t.Errorf("Wait() after stale release = %v, want %v", wait, policy.BackoffBase)
+	}
+}
+
+func TestReleaseLoginAttemptOnlyOwnReservation(t *testing.T) {
+	fake := &fakeDB{}
+	db := openFakeDB(t, fake)
+
+	until := time.Date(2026, 1, 1, 12, 0, 10, 0, time.UTC)
+	reservation := models.LoginReservation{
+		models.ThrottleScopeLogin: until,
+		models.ThrottleScopeIP:    until.Add(-9 * time.Second),
+	}
+	if err := controllers.ReleaseLoginAttemptDataBase(db, "alice", "10.0.0.1", reservation); err != nil {
+		t.Fatalf("ReleaseLoginAttemptDataBase() error = %v", err)
+	}
+
+	calls := fake.called("SET blocked_until = NULL")
+	if len(calls) != 2 {
+		t.Fatalf("release updates = %d, want 2", len(calls))
+	}
+	for _, call := range calls {
+		if !strings.Contains(call.query, "blocked_until = $3") {
+			t.Errorf("release must match its own reservation: %s", call.query)
+		}
+		if want := reservation[call.args[0].(string)]; !call.args[2].(time.Time).Equal(want) {
+			t.Errorf("release of %v = %v, want %v", call.args[0], call.args[2], want)
+		}
+	}
+}
+
+// throttleTable хранит login_throttles в памяти для сценарной БД
+type throttleTable struct {
+	mu   sync.Mutex
+	rows map[string][]driver.Value // scope/subject -> failures, last_failure_at, blocked_until
+}
+
+func (tt *throttleTable) install(fake *fakeDB) {
+	tt.rows = map[string][]driver.Value{}
+	key := func(args []driver.Value) string { return args[0].(string) + "/" + args[1].(string) }
+
+	fake.on("SET blocked_until = NULL", func(args []driver.Value) ([]string, [][]driver.Value, error) {
+		tt.mu.Lock()
+		defer tt.mu.Unlock()
+		if row, ok := tt.rows[key(args)]; ok && row[2] != nil && row[2].(time.Time).Equal(args[2].(time.Time)) {
+			row[2] = nil
+		}
+		return nil, nil, nil
+	})
+	fake.on("UPDATE login_throttles SET failures", func(args []driver.Value) ([]string, [][]driver.Value, error) {
+		tt.mu.Lock()
+		defer tt.mu.Unlock()
+		tt.rows[key(args)] = []driver.Value{int64(args[2].(int)), args[3], args[4]}
+		return nil, nil, nil
+	})
+	fake.on("SELECT failures, last_failure_at, blocked_until", func(args []driver.Value) ([]string, [][]driver.Value, error) {
+		tt.mu.Lock()
+		defer tt.mu.Unlock()
+		row, ok := tt.rows[key(args)]
+		if !ok {
+			row = []driver.Value{int64(0), time.Now(), nil}
+			tt.rows[key(args)] = row
+		}
+		return []string{"failures", "last_failure_at", "blocked_until"}, [][]driver.Value{append([]driver.Value(nil), row...)}, nil
+	})
+}
+
+func TestTwoFactorManagementCodesThrottled(t *testing.T) {
+	fake := &fakeDB{}
+	(&throttleTable{}).install(fake)
+	// Шаг TOTP уже использован, поэтому любой код неверен
+	fake.row("FROM user_totp", "JBSWY3DPEHPK3PXP", true, int64(math.MaxInt64))
+	db := openFakeDB(t, fake)
+
+	app := handlers.NewApp(db, &config.Config{LoginMaxFailures: 5, LoginIPMaxFailures: 20}, nil, nil)
+	claims := &services.Claims{UserID: "b3f1c2d4-1111-4a2b-9c3d-000000000001", Login: "alice"}
+
+	disable := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodDelete, "/api/user/2fa", strings.NewReader(`{"code":"123456"}`))
+		r = r.WithContext(context.WithValue(r.Context(), "user", claims))
+		w := httptest.NewRecorder()
+		app.TwoFactorHandler(w, r)
+		return w
+	}
+
+	if w := disable(); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("first wrong code status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	// Следующая попытка отклоняется задержкой, код не проверяется
+	w := disable()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("repeated wrong code status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("throttled response must set Retry-After")
+	}
+	if checks := len(fake.called("FROM user_totp")); checks != 1 {
+		t.Errorf("codes checked = %d, want 1", checks)
+	}
+}
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"replace field", `{"title":"a","description":"b"}`, `{"title":"c"}`, `{"title":"c","description":"b"}`},
+		{"null removes field", `{"title":"a","due_date":"2025-12-15"}`, `{"due_date":null}`, `{"title":"a"}`},
+		{"nested merge", `{"recurrence":{"freq":"daily","interval":2}}`, `{"recurrence":{"interval":3}}`, `{"recurrence":{"freq":"daily","interval":3}}`},
+		{"array replaced", `{"tags":["a","b"]}`, `{"tags":["c"]}`, `{"tags":["c"]}`},
+		{"empty patch", `{"title":"a"}`, `{}`, `{"title":"a"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := models.MergePatch([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+
+			var gotValue, wantValue interface{}
+			json.Unmarshal(got, &gotValue)
+			json.Unmarshal([]byte(tt.want), &wantValue)
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("MergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
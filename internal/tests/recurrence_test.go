@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceNext(t *testing.T) {
+	count := 3
+	until := "2025-12-20"
+
+	tests := []struct {
+		name       string
+		recurrence models.Recurrence
+		from       string
+		occurrence int
+		want       string
+		wantOk     bool
+	}{
+		{
+			name:       "daily",
+			recurrence: models.Recurrence{Freq: "daily"},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-16",
+			wantOk:     true,
+		},
+		{
+			name:       "every 3 days",
+			recurrence: models.Recurrence{Freq: "daily", Interval: 3},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-18",
+			wantOk:     true,
+		},
+		{
+			name:       "weekly without weekdays",
+			recurrence: models.Recurrence{Freq: "weekly"},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-22",
+			wantOk:     true,
+		},
+		{
+			name:       "weekly on monday and thursday",
+			recurrence: models.Recurrence{Freq: "weekly", Weekdays: []int{1, 4}},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-18",
+			wantOk:     true,
+		},
+		{
+			name:       "every other week on monday",
+			recurrence: models.Recurrence{Freq: "weekly", Interval: 2, Weekdays: []int{1}},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-29",
+			wantOk:     true,
+		},
+		{
+			name:       "monthly on day 31 clamps to february",
+			recurrence: models.Recurrence{Freq: "monthly", MonthDay: 31},
+			from:       "2026-01-31",
+			occurrence: 1,
+			want:       "2026-02-28",
+			wantOk:     true,
+		},
+		{
+			name:       "monthly on later day in same month",
+			recurrence: models.Recurrence{Freq: "monthly", MonthDay: 20},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-20",
+			wantOk:     true,
+		},
+		{
+			name:       "count exhausted",
+			recurrence: models.Recurrence{Freq: "daily", Count: &count},
+			from:       "2025-12-15",
+			occurrence: 3,
+			wantOk:     false,
+		},
+		{
+			name:       "until reached",
+			recurrence: models.Recurrence{Freq: "weekly", Until: &until},
+			from:       "2025-12-15",
+			occurrence: 1,
+			wantOk:     false,
+		},
+		{
+			name:       "until includes last day",
+			recurrence: models.Recurrence{Freq: "daily", Interval: 5, Until: &until},
+			from:       "2025-12-15",
+			occurrence: 1,
+			want:       "2025-12-20",
+			wantOk:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := time.Parse("2006-01-02", tt.from)
+
+			got, ok := tt.recurrence.Next(from, tt.occurrence)
+			if ok != tt.wantOk {
+				t.Fatalf("Next() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.Format("2006-01-02") != tt.want {
+				t.Errorf("Next() = %s, want %s", got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence models.Recurrence
+		wantErr    bool
+	}{
+		{"valid monthly", models.Recurrence{Freq: "monthly", MonthDay: 15}, false},
+		{"unknown freq", models.Recurrence{Freq: "yearly"}, true},
+		{"weekdays on daily", models.Recurrence{Freq: "daily", Weekdays: []int{1}}, true},
+		{"invalid weekday", models.Recurrence{Freq: "weekly", Weekdays: []int{7}}, true},
+		{"invalid month day", models.Recurrence{Freq: "monthly", MonthDay: 32}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.recurrence.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecurrenceAnchorMonthDay(t *testing.T) {
+	dueDate := "2026-01-31T23:59:59+03:00"
+	recurrence := &models.Recurrence{Freq: "monthly"}
+	recurrence.AnchorMonthDay(&dueDate)
+
+	if recurrence.MonthDay != 31 {
+		t.Fatalf("AnchorMonthDay() month_day = %d, want 31", recurrence.MonthDay)
+	}
+
+	// Серия возвращается на 31 число после короткого месяца
+	from, _ := time.Parse(time.RFC3339, dueDate)
+	want := []string{"2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"}
+	for i, date := range want {
+		next, ok := recurrence.Next(from, i+1)
+		if !ok || next.Format("2006-01-02") != date {
+			t.Fatalf("occurrence %d = %s, want %s", i+2, next.Format("2006-01-02"), date)
+		}
+		from = next
+	}
+
+	// Явный month_day и другие частоты не меняются
+	explicit := &models.Recurrence{Freq: "monthly", MonthDay: 5}
+	explicit.AnchorMonthDay(&dueDate)
+	weekly := &models.Recurrence{Freq: "weekly"}
+	weekly.AnchorMonthDay(&dueDate)
+	if explicit.MonthDay != 5 || weekly.MonthDay != 0 {
+		t.Errorf("unexpected anchors: monthly %d, weekly %d", explicit.MonthDay, weekly.MonthDay)
+	}
+
+	var none *models.Recurrence
+	none.AnchorMonthDay(&dueDate)
+}
@@ -0,0 +1,31 @@
+package tests
+
+import (
+	"TaskManager/internal/services"
+	"testing"
+)
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := services.GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+
+	if token == "" || len(hash) != 64 {
+		t.Fatalf("unexpected token %q or hash %q", token, hash)
+	}
+	if hash == token {
+		t.Error("hash must differ from the token")
+	}
+	if services.HashRefreshToken(token) != hash {
+		t.Error("HashRefreshToken() must match the generated hash")
+	}
+
+	other, _, err := services.GenerateRefreshToken()
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken() error = %v", err)
+	}
+	if other == token {
+		t.Error("tokens must be unique")
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"testing"
+	"time"
+)
+
+func TestSavedViewValidation(t *testing.T) {
+	nextWeek := "today+7"
+	badDate := "tomorrow"
+
+	tests := []struct {
+		name    string
+		view    models.SavedView
+		wantErr bool
+	}{
+		{"valid", models.SavedView{Name: "Срочное на неделе", Filters: models.SavedViewFilters{Priorities: []string{"high"}, DueTo: &nextWeek}}, false},
+		{"overdue", models.SavedView{Name: "Overdue", Filters: models.SavedViewFilters{Overdue: true}}, false},
+		{"empty name", models.SavedView{Name: "  "}, true},
+		{"invalid status", models.SavedView{Name: "x", Filters: models.SavedViewFilters{Statuses: []string{"archived"}}}, true},
+		{"invalid date", models.SavedView{Name: "x", Filters: models.SavedViewFilters{DueFrom: &badDate}}, true},
+		{"invalid sort", models.SavedView{Name: "x", Filters: models.SavedViewFilters{Sort: "rank"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.view.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSavedViewRelativeDates(t *testing.T) {
+	dueTo := "today+7"
+	filters := models.SavedViewFilters{DueTo: &dueTo}
+
+	filter := filters.TaskFilter(time.UTC)
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	want := time.Now().UTC().AddDate(0, 0, 7).Format("2006-01-02")
+	if *filter.DueTo != want {
+		t.Errorf("DueTo = %s, want %s", *filter.DueTo, want)
+	}
+	if *filters.DueTo != "today+7" {
+		t.Errorf("saved filter was modified: %s", *filters.DueTo)
+	}
+}
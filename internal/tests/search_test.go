@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestSearchRequestValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.SearchRequest
+		wantErr bool
+	}{
+		{"valid", models.SearchRequest{Query: "отчет"}, false},
+		{"empty query", models.SearchRequest{Query: "   "}, true},
+		{"long query", models.SearchRequest{Query: strings.Repeat("a", 201)}, true},
+		{"limit too big", models.SearchRequest{Query: "report", Limit: 101}, true},
+		{"invalid cursor", models.SearchRequest{Query: "report", Cursor: "???"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSearchRequestDefaults(t *testing.T) {
+	request := models.SearchRequest{Query: "  report  "}
+	if err := request.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if request.Query != "report" || request.Limit != models.DefaultSearchLimit {
+		t.Errorf("unexpected defaults: %+v", request)
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"testing"
+)
+
+func TestTaskFilterValidation(t *testing.T) {
+	validDate := "2025-12-15"
+	invalidDate := "15.12.2025"
+
+	tests := []struct {
+		name    string
+		filter  models.TaskFilter
+		wantErr bool
+	}{
+		{
+			name:    "empty filter",
+			filter:  models.TaskFilter{},
+			wantErr: false,
+		},
+		{
+			name: "full filter",
+			filter: models.TaskFilter{
+				Statuses:   []string{"active"},
+				Priorities: []string{"high", "medium"},
+				DueFrom:    &validDate,
+				DueTo:      &validDate,
+				Query:      "report",
+				Sort:       "due_date",
+				Order:      "asc",
+				Limit:      20,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid status",
+			filter:  models.TaskFilter{Statuses: []string{"done"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid priority",
+			filter:  models.TaskFilter{Priorities: []string{"urgent"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid due date",
+			filter:  models.TaskFilter{DueFrom: &invalidDate},
+			wantErr: true,
+		},
+		{
+			name:    "invalid sort",
+			filter:  models.TaskFilter{Sort: "user_id"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid order",
+			filter:  models.TaskFilter{Order: "up"},
+			wantErr: true,
+		},
+		{
+			name:    "limit too large",
+			filter:  models.TaskFilter{Limit: 1000},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cursor",
+			filter:  models.TaskFilter{Cursor: "not-a-cursor"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaskFilterDefaults(t *testing.T) {
+	filter := models.TaskFilter{}
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if filter.Sort != "created_at" || filter.Order != "desc" || filter.Limit != models.DefaultTasksLimit {
+		t.Errorf("unexpected defaults: sort=%s order=%s limit=%d", filter.Sort, filter.Order, filter.Limit)
+	}
+}
+
+func TestTaskCursorRoundTrip(t *testing.T) {
+	cursor := models.TaskCursor{
+		Key: "2025-12-15 10:00:00.123456",
+		ID:  "00000000-0000-0000-0000-000000000000",
+	}
+
+	decoded, err := models.DecodeTaskCursor(models.EncodeTaskCursor(cursor))
+	if err != nil {
+		t.Fatalf("DecodeTaskCursor() error = %v", err)
+	}
+
+	if decoded != cursor {
+		t.Errorf("DecodeTaskCursor() = %v, want %v", decoded, cursor)
+	}
+}
+
+func TestTaskFilterCursorSortMismatch(t *testing.T) {
+	cursor := models.EncodeTaskCursor(models.TaskCursor{
+		Key:   "2025-12-15 10:00:00.123456",
+		ID:    "00000000-0000-0000-0000-000000000000",
+		Sort:  "created_at",
+		Order: "desc",
+	})
+
+	same := models.TaskFilter{Cursor: cursor}
+	if err := same.Validate(); err != nil {
+		t.Errorf("Validate() with matching cursor error = %v", err)
+	}
+
+	for _, filter := range []models.TaskFilter{
+		{Cursor: cursor, Sort: "title"},
+		{Cursor: cursor, Order: "asc"},
+	} {
+		if err := filter.Validate(); err == nil {
+			t.Errorf("expected cursor of another sort to be rejected: sort=%s order=%s", filter.Sort, filter.Order)
+		}
+	}
+
+	// Курсор без сортировки (например, из поиска) для списка задач не подходит
+	foreign := models.TaskFilter{Cursor: models.EncodeTaskCursor(models.TaskCursor{
+		Key: "0.5",
+		ID:  "00000000-0000-0000-0000-000000000000",
+	})}
+	if err := foreign.Validate(); err == nil {
+		t.Error("expected cursor without sort to be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"TaskManager/internal/controllers"
+	"testing"
+	"time"
+)
+
+func TestGetTaskDueDateInUTC(t *testing.T) {
+	const (
+		userID = "00000000-0000-4000-8000-000000000001"
+		taskID = "00000000-0000-4000-8000-000000000010"
+	)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	due := time.Date(2026, 3, 2, 23, 59, 59, 0, moscow)
+	created := time.Date(2026, 3, 1, 10, 0, 0, 0, moscow)
+
+	fake := &fakeDB{}
+	fake.row("FROM tasks t",
+		taskID, "Отчет", "", "active", "medium", due, created, created,
+		false, false, nil, nil, []byte("{}"), nil, nil, nil,
+		int64(1), int64(1), float64(1), int64(0), int64(0), "owner")
+
+	db := openFakeDB(t, fake)
+	user, id := userID, taskID
+	task, err := controllers.GetTaskDataBase(db, &user, &id)
+	if err != nil {
+		t.Fatalf("GetTaskDataBase() error = %v", err)
+	}
+
+	if task.DueDate == nil || *task.DueDate != "2026-03-02T20:59:59Z" {
+		t.Errorf("DueDate = %v, want 2026-03-02T20:59:59Z", task.DueDate)
+	}
+}
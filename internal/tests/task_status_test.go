@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"TaskManager/internal/controllers"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestToggleCascadeContinuesRecurringSubtasks(t *testing.T) {
+	const (
+		userID   = "00000000-0000-4000-8000-000000000001"
+		parentID = "00000000-0000-4000-8000-000000000010"
+		subID    = "00000000-0000-4000-8000-000000000011"
+		nextID   = "00000000-0000-4000-8000-000000000012"
+	)
+	due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	fake := &fakeDB{}
+	fake.row("THEN 'owner'", "owner")
+	fake.row("SELECT status FROM tasks WHERE id = $1", "active")
+	fake.row("FROM task_dependencies d", false)
+	fake.row("array_to_string", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	fake.on("SELECT t.recurrence, t.due_date", func(args []driver.Value) ([]string, [][]driver.Value, error) {
+		columns := []string{"recurrence", "due_date", "series_id", "occurrence", "timezone", "user_id"}
+		if args[0] == subID {
+			return columns, [][]driver.Value{{[]byte(`{"freq":"daily"}`), due, subID, int64(1), "UTC", userID}}, nil
+		}
+		return columns, [][]driver.Value{{nil, nil, parentID, int64(1), "UTC", userID}}, nil
+	})
+	fake.row("SELECT count(*)", int64(0))
+	fake.row("INSERT INTO tasks", nextID)
+	fake.row("WITH RECURSIVE subtree", subID)
+
+	db := openFakeDB(t, fake)
+	parent, user := parentID, userID
+	if err := controllers.ToggleTaskStatusDataBase(db, &parent, &user, true, false); err != nil {
+		t.Fatalf("ToggleTaskStatusDataBase() error = %v", err)
+	}
+
+	inserts := fake.called("INSERT INTO tasks")
+	if len(inserts) != 1 {
+		t.Fatalf("next occurrences created = %d, want 1", len(inserts))
+	}
+	if inserts[0].args[1] != subID || !inserts[0].args[2].(time.Time).Equal(due.AddDate(0, 0, 1)) {
+		t.Errorf("next occurrence = %v, want of %s due %s", inserts[0].args, subID, due.AddDate(0, 0, 1))
+	}
+}
@@ -2,11 +2,13 @@ package tests
 
 import (
 	"TaskManager/internal/models"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestTaskValidation(t *testing.T) {
-	validDueDate := "2025-12-15"
+	validDueDate := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
 
 	tests := []struct {
 		name    string
@@ -72,3 +74,88 @@ func TestTaskValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeDueDate(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("tzdata is not available")
+	}
+
+	tests := []struct {
+		name    string
+		dueDate string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "date only is end of day",
+			dueDate: "2025-12-15",
+			want:    "2025-12-15T23:59:59+03:00",
+		},
+		{
+			name:    "local time",
+			dueDate: "2025-12-15T14:00",
+			want:    "2025-12-15T14:00:00+03:00",
+		},
+		{
+			name:    "rfc 3339 is converted to user zone",
+			dueDate: "2025-12-15T11:00:00Z",
+			want:    "2025-12-15T14:00:00+03:00",
+		},
+		{
+			name:    "invalid format",
+			dueDate: "15.12.2025",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := models.NormalizeDueDate(&tt.dueDate, moscow)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeDueDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("NormalizeDueDate() = %s, want %s", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskValidationFieldErrors(t *testing.T) {
+	pastDueDate := time.Now().AddDate(0, 0, -7).Format(time.RFC3339)
+
+	task := models.Task{
+		Title:    "",
+		Priority: "urgent",
+		DueDate:  &pastDueDate,
+	}
+
+	err := task.Validate()
+
+	var errs models.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+
+	want := map[string]string{
+		"title":    models.CodeRequired,
+		"priority": models.CodeInvalidEnum,
+		"due_date": models.CodeInPast,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for _, fieldErr := range errs {
+		if want[fieldErr.Field] != fieldErr.Code {
+			t.Errorf("field %s: code = %s, want %s", fieldErr.Field, fieldErr.Code, want[fieldErr.Field])
+		}
+	}
+
+	// При изменении задачи срок в прошлом допустим
+	task.Title = "Test Task"
+	task.Priority = "high"
+	if err := task.ValidateUpdate(); err != nil {
+		t.Errorf("ValidateUpdate() error = %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"TaskManager/internal/services"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Секрет "12345678901234567890" из тестовых векторов RFC 6238
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFCVectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := services.TOTPCode(rfcTOTPSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		if code != tt.code {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := services.TOTPStep(now)
+
+	previous, _ := services.TOTPCode(rfcTOTPSecret, now.Add(-30*time.Second))
+	if got, ok := services.ValidateTOTP(rfcTOTPSecret, previous, now, 0); !ok || got != step-1 {
+		t.Errorf("code of previous step must be accepted, got step %d ok %v", got, ok)
+	}
+
+	old, _ := services.TOTPCode(rfcTOTPSecret, now.Add(-90*time.Second))
+	if _, ok := services.ValidateTOTP(rfcTOTPSecret, old, now, 0); ok {
+		t.Error("code outside of the window must be rejected")
+	}
+
+	current, _ := services.TOTPCode(rfcTOTPSecret, now)
+	if _, ok := services.ValidateTOTP(rfcTOTPSecret, current, now, step); ok {
+		t.Error("already used step must be rejected")
+	}
+
+	if _, ok := services.ValidateTOTP(rfcTOTPSecret, "12345", now, 0); ok {
+		t.Error("short code must be rejected")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := services.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("expected 32 base32 chars, got %q", secret)
+	}
+
+	code, err := services.TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	if _, ok := services.ValidateTOTP(secret, code, time.Now(), 0); !ok {
+		t.Error("fresh code must be accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(services.TOTPURI("user@example.com", rfcTOTPSecret))
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("unexpected uri: %s", uri)
+	}
+	if uri.Path != "/TaskManager:user@example.com" {
+		t.Errorf("unexpected label: %s", uri.Path)
+	}
+
+	query := uri.Query()
+	if query.Get("secret") != rfcTOTPSecret || query.Get("issuer") != "TaskManager" || query.Get("digits") != "6" {
+		t.Errorf("unexpected query: %v", query)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := services.GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != services.RecoveryCodesCount || len(hashes) != len(codes) {
+		t.Fatalf("expected %d codes, got %d", services.RecoveryCodesCount, len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' || services.IsTOTPCode(code) {
+			t.Errorf("unexpected code format: %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code: %q", code)
+		}
+		seen[code] = true
+
+		// Ввод без дефиса и в другом регистре дает тот же хеш
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		if services.HashRecoveryCode(typed) != hashes[i] {
+			t.Errorf("hash of %q does not match", typed)
+		}
+	}
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"123456":      true,
+		"12345":       false,
+		"12345a":      false,
+		"abcde-fghij": false,
+	} {
+		if got := services.IsTOTPCode(code); got != want {
+			t.Errorf("IsTOTPCode(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"TaskManager/internal/models"
+	"testing"
+)
+
+func TestWorkflowValidation(t *testing.T) {
+	states := func() []models.WorkflowState {
+		return []models.WorkflowState{
+			{Name: "todo", Category: "active"},
+			{Name: "review", Category: "active"},
+			{Name: "done", Category: "completed"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		workflow models.Workflow
+		wantErr  bool
+	}{
+		{"valid", models.Workflow{States: states(), Transitions: []models.WorkflowTransition{{From: "todo", To: "review"}}}, false},
+		{"no states", models.Workflow{}, true},
+		{"no completed state", models.Workflow{States: []models.WorkflowState{{Name: "todo", Category: "active"}}}, true},
+		{"duplicate state", models.Workflow{States: append(states(), models.WorkflowState{Name: "done", Category: "completed"})}, true},
+		{"invalid category", models.Workflow{States: append(states(), models.WorkflowState{Name: "hold", Category: "paused"})}, true},
+		{"completed initial", models.Workflow{States: []models.WorkflowState{{Name: "todo", Category: "active"}, {Name: "done", Category: "completed", Initial: true}}}, true},
+		{"unknown transition state", models.Workflow{States: states(), Transitions: []models.WorkflowTransition{{From: "todo", To: "qa"}}}, true},
+		{"self transition", models.Workflow{States: states(), Transitions: []models.WorkflowTransition{{From: "todo", To: "todo"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.workflow.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowDefaultsInitialState(t *testing.T) {
+	workflow := models.Workflow{States: []models.WorkflowState{
+		{Name: "done", Category: "completed"},
+		{Name: "todo", Category: "active"},
+	}}
+
+	if err := workflow.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !workflow.States[1].Initial || workflow.States[0].Initial {
+		t.Errorf("expected first active state to be initial, got %+v", workflow.States)
+	}
+	if workflow.States[0].Position != 1 || workflow.States[1].Position != 2 {
+		t.Errorf("unexpected positions: %+v", workflow.States)
+	}
+}
@@ -36,10 +36,43 @@ func main() {
 	log.Println("Успешное подключение к БД")
 
 	// Создаем JWT сервис
-	jwtService := services.NewJWTService(cfg.JWTSecret)
+	accessTTL, err := time.ParseDuration(cfg.AccessTokenTTL)
+	if err != nil {
+		accessTTL = 15 * time.Minute // значение по умолчанию
+	}
+	refreshTTL, err := time.ParseDuration(cfg.RefreshTokenTTL)
+	if err != nil {
+		refreshTTL = 30 * 24 * time.Hour // значение по умолчанию
+	}
+
+	jwtService, err := services.NewJWTService(cfg.JWTKeysDir, cfg.JWTSigningKeyID, accessTTL, refreshTTL)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки ключей JWT: %v", err)
+	}
+	log.Printf("Токены подписываются ключом %s\n", jwtService.SigningKeyID())
+
+	// Перечитываем набор ключей по SIGHUP (ротация без перезапуска)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := jwtService.ReloadKeys(); err != nil {
+				log.Printf("Ошибка перезагрузки ключей JWT: %v", err)
+				continue
+			}
+			log.Printf("Ключи JWT перезагружены, ключ подписи %s\n", jwtService.SigningKeyID())
+		}
+	}()
+
+	// Инициализируем Kafka Producer
+	kafkaProducer, err := services.NewKafkaProducer(cfg.KafkaBrokers, cfg.KafkaNotificationTopic)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации Kafka Producer: %v", err)
+	}
+	defer kafkaProducer.Close()
 
 	// Создаем экземпляр приложения
-	app := handlers.NewApp(db, cfg, jwtService)
+	app := handlers.NewApp(db, cfg, jwtService, kafkaProducer)
 
 	// Обслуживание статических файлов
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("./static"))))
@@ -47,9 +80,14 @@ func main() {
 	// API маршруты
 	http.HandleFunc("/api/register", app.ApiMiddleware(app.RegisterHandler))
 	http.HandleFunc("/api/login", app.ApiMiddleware(app.LoginHandler))
+	http.HandleFunc("/api/login/2fa", app.ApiMiddleware(app.LoginTwoFactorHandler))
 	http.HandleFunc("/api/refresh", app.ApiMiddleware(app.RefreshTokenHandler))
+	http.HandleFunc("/api/logout", app.ApiMiddleware(app.LogoutHandler))
 	http.HandleFunc("/api/health", app.ApiMiddleware(app.HealthHandler))
 
+	// Открытые ключи для проверки токенов другими сервисами
+	http.HandleFunc("/.well-known/jwks.json", app.ApiMiddleware(app.JWKSHandler))
+
 	// Защищенные API маршруты
 	http.HandleFunc("/api/me", app.ProtectedApiMiddleware(app.MeHandler))
 
@@ -78,6 +116,56 @@ func main() {
 			return
 		}
 
+		// Корзина
+		if parts[0] == "trash" {
+			app.TrashHandler(w, r)
+			return
+		}
+
+		// Массовые операции
+		if parts[0] == "bulk" {
+			app.BulkTasksHandler(w, r)
+			return
+		}
+
+		// Вложенные ресурсы задачи
+		if len(parts) > 1 && parts[1] == "reminders" {
+			app.TaskRemindersHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "members" {
+			app.TaskMembersHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "comments" {
+			app.TaskCommentsHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "history" {
+			app.TaskHistoryHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "restore" {
+			app.RestoreTaskHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "purge" {
+			app.PurgeTaskHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "transition" {
+			app.TransitionTaskHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "move" {
+			app.MoveTaskHandler(w, r)
+			return
+		}
+		if len(parts) > 1 && parts[1] == "dependencies" {
+			app.TaskDependenciesHandler(w, r)
+			return
+		}
+
 		// Обрабатываем разные методы
 		switch r.Method {
 		case http.MethodPut:
@@ -90,22 +178,59 @@ func main() {
 				//w.WriteHeader(http.StatusNotImplemented)
 				//json.NewEncoder(w).Encode(map[string]string{"error": "Обновление задачи пока не реализовано"})
 			}
+		case http.MethodPatch:
+			app.PatchTaskHandler(w, r)
 		case http.MethodDelete:
 			app.DeleteTaskHandler(w, r)
 		case http.MethodGet:
-			app.GetTaskHandler(w, r)
+			if len(parts) > 1 && parts[1] == "subtasks" {
+				app.GetSubtasksHandler(w, r)
+			} else {
+				app.GetTaskHandler(w, r)
+			}
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			json.NewEncoder(w).Encode(map[string]string{"error": "Метод не поддерживается"})
 		}
 	}))
 
+	// Проекты
+	http.HandleFunc("/api/projects", app.ProtectedApiMiddleware(app.ProjectsHandler))
+	http.HandleFunc("/api/projects/", app.ProtectedApiMiddleware(app.ProjectHandler))
+
+	// Теги
+	http.HandleFunc("/api/tags", app.ProtectedApiMiddleware(app.TagsHandler))
+
+	// Сохраненные представления
+	http.HandleFunc("/api/views", app.ProtectedApiMiddleware(app.SavedViewsHandler))
+	http.HandleFunc("/api/views/", app.ProtectedApiMiddleware(app.SavedViewHandler))
+
+	// Полнотекстовый поиск
+	http.HandleFunc("/api/search", app.ProtectedApiMiddleware(app.SearchHandler))
+
+	// Доска задач
+	http.HandleFunc("/api/board", app.ProtectedApiMiddleware(app.BoardHandler))
+
+	// Пользовательский процесс состояний задач
+	http.HandleFunc("/api/workflow", app.ProtectedApiMiddleware(app.WorkflowHandler))
+
 	// Обработчик смены пароля
 	http.HandleFunc("/api/user/password", app.ProtectedApiMiddleware(app.SaveUserPasswordHandler))
 
+	// Сессии пользователя
+	http.HandleFunc("/api/user/sessions", app.ProtectedApiMiddleware(app.UserSessionsHandler))
+	http.HandleFunc("/api/user/sessions/", app.ProtectedApiMiddleware(app.UserSessionsHandler))
+
+	// Двухфакторная аутентификация
+	http.HandleFunc("/api/user/2fa", app.ProtectedApiMiddleware(app.TwoFactorHandler))
+	http.HandleFunc("/api/user/2fa/", app.ProtectedApiMiddleware(app.TwoFactorHandler))
+
 	// Обработка смены логина
 	http.HandleFunc("/api/user/email", app.ProtectedApiMiddleware(app.SaveUserEmailHandler))
 
+	// Обработка смены часового пояса
+	http.HandleFunc("/api/user/timezone", app.ProtectedApiMiddleware(app.SaveUserTimezoneHandler))
+
 	// Страницы
 	http.HandleFunc("/", app.RegisterFormHandler)
 	http.HandleFunc("/dashboard", app.DashboardHandler)
@@ -126,13 +251,6 @@ func main() {
 		}
 	}()
 
-	// Инициализируем Kafka Producer
-	kafkaProducer, err := services.NewKafkaProducer(cfg.KafkaBrokers, cfg.KafkaNotificationTopic)
-	if err != nil {
-		log.Fatalf("Ошибка инициализации Kafka Producer: %v", err)
-	}
-	defer kafkaProducer.Close()
-
 	// Создаем и запускаем сервис проверки задач
 	interval, err := time.ParseDuration(cfg.NotificationCheckInterval)
 	if err != nil {
@@ -142,6 +260,19 @@ func main() {
 	taskChecker := services.NewTaskChecker(db, kafkaProducer, interval)
 	go taskChecker.Start()
 
+	// Запускаем очистку корзины
+	trashRetention, err := time.ParseDuration(cfg.TrashRetention)
+	if err != nil {
+		trashRetention = 30 * 24 * time.Hour // значение по умолчанию
+	}
+	trashInterval, err := time.ParseDuration(cfg.TrashPurgeInterval)
+	if err != nil {
+		trashInterval = time.Hour // значение по умолчанию
+	}
+
+	trashCleaner := services.NewTrashCleaner(db, trashRetention, trashInterval)
+	go trashCleaner.Start()
+
 	// Ожидание сигнала завершения
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
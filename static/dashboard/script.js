@@ -3,9 +3,15 @@ const API_BASE = 'http://localhost:8000/api';
 // Элементы DOM
 let currentUser = null;
 let currentEditingTaskId = null;
+let currentEditingRecurrence = null;
+let currentEditingETag = null;
+let currentCreateKey = null;
+let currentView = 'list';
+let draggedTaskId = null;
 let currentPage = 1;
 const tasksPerPage = 10;
 let allTasks = [];
+let allProjects = [];
 let filteredTasks = [];
 let productivityChart = null;
 let priorityChart = null;
@@ -18,11 +24,59 @@ document.addEventListener('DOMContentLoaded', async () => {
     if (isAuthenticated) {
         // Если аутентифицирован, загружаем остальные данные
         await loadUserData();
+        await loadProjects();
         await loadTasks();
         setupEventListeners();
     }
 });
 
+// Обмен refresh токена на новую пару токенов
+async function refreshAuthToken() {
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (!refreshToken) {
+        return false;
+    }
+
+    const response = await fetch(`${API_BASE}/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken })
+    });
+
+    if (!response.ok) {
+        return false;
+    }
+
+    const result = await response.json();
+    localStorage.setItem('authToken', result.token);
+    localStorage.setItem('refreshToken', result.refresh_token);
+    localStorage.setItem('tokenExpiresAt', String(Date.now() + result.expires_in * 1000));
+    scheduleTokenRefresh();
+    return true;
+}
+
+// Обновляем access токен за минуту до истечения
+function scheduleTokenRefresh() {
+    const expiresAt = Number(localStorage.getItem('tokenExpiresAt') || 0);
+    const delay = Math.max(expiresAt - Date.now() - 60 * 1000, 0);
+
+    clearTimeout(scheduleTokenRefresh.timer);
+    scheduleTokenRefresh.timer = setTimeout(async () => {
+        if (!await refreshAuthToken()) {
+            clearAuthStorage();
+            window.location.href = '/';
+        }
+    }, delay);
+}
+
+function clearAuthStorage() {
+    localStorage.removeItem('authToken');
+    localStorage.removeItem('refreshToken');
+    localStorage.removeItem('tokenExpiresAt');
+    localStorage.removeItem('userId');
+    localStorage.removeItem('userLogin');
+}
+
 // Проверка аутентификации
 async function checkAuth() {
     const token = localStorage.getItem('authToken');
@@ -32,6 +86,13 @@ async function checkAuth() {
     }
 
     try {
+        // Истекший access токен сначала обновляем
+        const expiresAt = Number(localStorage.getItem('tokenExpiresAt') || 0);
+        if (expiresAt <= Date.now() && !await refreshAuthToken()) {
+            throw new Error('Session expired');
+        }
+        scheduleTokenRefresh();
+
         const response = await fetch(`${API_BASE}/me`, {
             headers: getAuthHeaders()
         });
@@ -45,9 +106,7 @@ async function checkAuth() {
         return true;
     } catch (error) {
         console.error('Auth check failed:', error);
-        localStorage.removeItem('authToken');
-        localStorage.removeItem('userId');
-        localStorage.removeItem('userLogin');
+        clearAuthStorage();
         window.location.href = '/';
         return false;
     }
@@ -79,23 +138,51 @@ function displayUserData(user) {
     document.getElementById('profileLogin').textContent = user.login;
     document.getElementById('profileCreateDate').textContent = new Date(user.create_at).toLocaleDateString();
     document.getElementById('profileEmail').textContent = user.email || 'Не указан';
+    document.getElementById('profileTimezone').textContent = user.timezone || 'UTC';
+    document.getElementById('newTimezone').value = user.timezone && user.timezone !== 'UTC'
+        ? user.timezone
+        : Intl.DateTimeFormat().resolvedOptions().timeZone;
 }
 
 // Загрузка задач
 async function loadTasks() {
     try {
-        const response = await fetch(`${API_BASE}/tasks`, {
-            headers: getAuthHeaders()
-        });
+        const tasks = [];
+        let cursor = '';
+
+        // Забираем все страницы списка задач
+        do {
+            const params = new URLSearchParams({ limit: '200' });
+            const project = document.getElementById('projectFilter').value;
+            if (project) {
+                params.set('project', project);
+            }
+            if (cursor) {
+                params.set('cursor', cursor);
+            }
 
-        if (response.ok) {
-            const tasks = await response.json();
-            allTasks = tasks;
-            filteredTasks = [...allTasks];
-            displayTasks();
-            updateStats(tasks);
-            updatePagination();
-            createCharts(tasks);
+            const response = await fetch(`${API_BASE}/tasks?${params}`, {
+                headers: getAuthHeaders()
+            });
+
+            if (!response.ok) {
+                throw new Error('Failed to load tasks');
+            }
+
+            const page = await response.json();
+            tasks.push(...page.tasks);
+            cursor = page.next_cursor || '';
+        } while (cursor);
+
+        allTasks = tasks;
+        filteredTasks = [...allTasks];
+        displayTasks();
+        updateStats(tasks);
+        updatePagination();
+        createCharts(tasks);
+
+        if (currentView === 'board') {
+            await loadBoard();
         }
     } catch (error) {
         console.error('Failed to load tasks:', error);
@@ -103,6 +190,186 @@ async function loadTasks() {
     }
 }
 
+// Переключение между списком и доской
+async function setTaskView(view) {
+    currentView = view;
+    document.querySelectorAll('.view-btn').forEach(btn => {
+        btn.classList.toggle('active', btn.dataset.view === view);
+    });
+
+    const isBoard = view === 'board';
+    document.getElementById('taskBoard').style.display = isBoard ? 'flex' : 'none';
+    document.getElementById('tasksList').style.display = isBoard ? 'none' : 'block';
+    document.querySelector('.filter-group').style.display = isBoard ? 'none' : '';
+    if (isBoard) {
+        document.getElementById('paginationContainer').style.display = 'none';
+        await loadBoard();
+    } else {
+        updatePagination();
+    }
+}
+
+// Загрузка доски
+async function loadBoard() {
+    try {
+        const params = new URLSearchParams();
+        const project = document.getElementById('projectFilter').value;
+        if (project) {
+            params.set('project', project);
+        }
+
+        const response = await fetch(`${API_BASE}/board?${params}`, {
+            headers: getAuthHeaders()
+        });
+
+        if (!response.ok) {
+            throw new Error('Failed to load board');
+        }
+
+        const board = await response.json();
+        displayBoard(board.columns);
+    } catch (error) {
+        console.error('Failed to load board:', error);
+        showNotification('Ошибка загрузки доски', 'error');
+    }
+}
+
+// Отображение доски
+function displayBoard(columns) {
+    const board = document.getElementById('taskBoard');
+    const titles = { active: 'Активные', completed: 'Выполненные' };
+
+    board.innerHTML = columns.map((column, index) => `
+        <div class="board-column" data-column="${index}">
+            <div class="board-column-header">
+                ${column.state ? escapeHtml(column.state) : (titles[column.status] || escapeHtml(column.status))}
+                <span class="board-column-count">${column.tasks.length}</span>
+            </div>
+            <div class="board-column-body">
+                ${column.tasks.map(task => `
+                    <div class="board-card priority-border-${task.priority}" data-task-id="${task.id}"
+                         draggable="${task.role !== 'viewer'}" ondblclick="editTask('${task.id}')">
+                        <div class="board-card-title">${escapeHtml(task.title)}</div>
+                        <div class="task-meta">
+                            ${task.state ? `<span class="task-state">${escapeHtml(task.state)}</span>` : ''}
+                            ${task.due_date ? formatDueDate(task.due_date) : ''}${task.overdue ? ' (просрочено)' : ''}
+                        </div>
+                    </div>
+                `).join('')}
+            </div>
+        </div>
+    `).join('');
+
+    board.querySelectorAll('.board-card').forEach(card => {
+        card.addEventListener('dragstart', () => {
+            draggedTaskId = card.dataset.taskId;
+            card.classList.add('dragging');
+        });
+        card.addEventListener('dragend', () => {
+            draggedTaskId = null;
+            card.classList.remove('dragging');
+        });
+    });
+
+    board.querySelectorAll('.board-column').forEach(column => {
+        const body = column.querySelector('.board-column-body');
+
+        column.addEventListener('dragover', (e) => {
+            e.preventDefault();
+            const dragging = board.querySelector('.board-card.dragging');
+            if (!dragging) {
+                return;
+            }
+
+            const next = getCardBelow(body, e.clientY);
+            if (next) {
+                body.insertBefore(dragging, next);
+            } else {
+                body.appendChild(dragging);
+            }
+        });
+
+        column.addEventListener('drop', async (e) => {
+            e.preventDefault();
+            const dragging = board.querySelector('.board-card.dragging');
+            if (!dragging || !draggedTaskId) {
+                return;
+            }
+
+            const previous = dragging.previousElementSibling;
+            const next = dragging.nextElementSibling;
+            const target = columns[column.dataset.column];
+            await moveTask(draggedTaskId, target.status, target.state,
+                previous ? previous.dataset.taskId : null,
+                next ? next.dataset.taskId : null);
+        });
+    });
+}
+
+// Карточка, перед которой нужно вставить перетаскиваемую
+function getCardBelow(body, y) {
+    const cards = [...body.querySelectorAll('.board-card:not(.dragging)')];
+    return cards.find(card => {
+        const box = card.getBoundingClientRect();
+        return y < box.top + box.height / 2;
+    }) || null;
+}
+
+// Перемещение задачи по доске; колонка процесса задается состоянием, иначе статусом
+async function moveTask(taskId, status, state, afterId, beforeId) {
+    try {
+        const move = state ? { state } : { status };
+        const response = await fetch(`${API_BASE}/tasks/${taskId}/move`, {
+            method: 'POST',
+            headers: getAuthHeaders(),
+            body: JSON.stringify({ ...move, after_id: afterId, before_id: beforeId })
+        });
+
+        if (response.status === 409) {
+            showNotification('Доска изменилась, обновляем', 'error');
+        } else if (!response.ok) {
+            throw new Error('Failed to move task');
+        }
+    } catch (error) {
+        console.error('Failed to move task:', error);
+        showNotification('Ошибка перемещения задачи', 'error');
+    }
+
+    await loadTasks();
+}
+
+// Загрузка проектов
+async function loadProjects() {
+    try {
+        const response = await fetch(`${API_BASE}/projects`, {
+            headers: getAuthHeaders()
+        });
+
+        if (!response.ok) {
+            throw new Error('Failed to load projects');
+        }
+
+        allProjects = await response.json();
+
+        const options = allProjects.map(project =>
+            `<option value="${project.id}">${escapeHtml(project.name)}</option>`
+        ).join('');
+
+        document.getElementById('projectFilter').innerHTML = `
+            <option value="">Все проекты</option>
+            <option value="none">Без проекта</option>
+            ${options}
+        `;
+        document.getElementById('taskProject').innerHTML = `
+            <option value="">Без проекта</option>
+            ${options}
+        `;
+    } catch (error) {
+        console.error('Failed to load projects:', error);
+        showNotification('Ошибка загрузки проектов', 'error');
+    }
+}
+
 // Отображение задач с пагинацией
 function displayTasks() {
     const tasksList = document.getElementById('tasksList');
@@ -137,25 +404,31 @@ function displayTasks() {
             ${task.description ? `<div class="task-description">${escapeHtml(task.description)}</div>` : ''}
             <div class="task-footer">
                 <div class="task-meta">
-                    ${task.due_date ? `Срок: ${new Date(task.due_date).toLocaleDateString()}` : 'Без срока'}
+                    ${task.state ? `<span class="task-state">${escapeHtml(task.state)}</span>` : ''}
+                    ${task.due_date ? `Срок: ${formatDueDate(task.due_date)}` : 'Без срока'}${task.overdue ? ' (просрочено)' : ''}
+                    ${(task.tags || []).map(tag => `<span class="task-tag">#${escapeHtml(tag)}</span>`).join('')}
                 </div>
                 <div class="task-actions">
+                    ${task.blocked ? `<span class="task-blocked" title="Ждет завершения других задач">🔒</span>` : ''}
+                    ${task.role !== 'owner' ? `<span class="task-role" title="Общая задача">👥</span>` : ''}
+                    ${task.role !== 'viewer' ? `
                     <button class="task-action-btn" onclick="toggleTaskStatus('${task.id}')" title="${task.status === 'completed' ? 'Вернуть в работу' : 'Завершить'}">
                         ${task.status === 'completed' ? '↶' : '✓'}
                     </button>
                     <button class="task-action-btn" onclick="editTask('${task.id}')" title="Редактировать">
                         ✏️
-                    </button>
+                    </button>` : ''}
+                    ${task.role === 'owner' ? `
                     <button class="task-action-btn" onclick="deleteTask('${task.id}')" title="Удалить">
                         🗑️
-                    </button>
+                    </button>` : ''}
                 </div>
             </div>
         </div>
     `).join('');
 
     // Показываем пагинацию если есть задачи
-    paginationContainer.style.display = filteredTasks.length > tasksPerPage ? 'block' : 'none';
+    paginationContainer.style.display = currentView === 'list' && filteredTasks.length > tasksPerPage ? 'block' : 'none';
 
     // Обновляем информацию о количестве задач
     document.getElementById('tasksShown').textContent = tasksToShow.length;
@@ -268,9 +541,19 @@ function updateStats(tasks) {
 // Создание новой задачи
 async function createTask(taskData) {
     try {
+        // Один ключ на открытие формы: повторная отправка не создаст дубликат
+        if (!currentCreateKey && window.crypto && crypto.randomUUID) {
+            currentCreateKey = crypto.randomUUID();
+        }
+
+        const headers = getAuthHeaders();
+        if (currentCreateKey) {
+            headers['Idempotency-Key'] = currentCreateKey;
+        }
+
         const response = await fetch(`${API_BASE}/tasks`, {
             method: 'POST',
-            headers: getAuthHeaders(),
+            headers: headers,
             body: JSON.stringify(taskData)
         });
 
@@ -278,6 +561,13 @@ async function createTask(taskData) {
             showNotification('Задача успешно создана', 'success');
             closeTaskModal();
             await loadTasks();
+            return;
+        }
+
+        // Сервер отклонил запрос, исправленная форма отправится с новым ключом
+        currentCreateKey = null;
+        if (response.status === 422) {
+            showValidationErrors(await response.json());
         } else {
             throw new Error('Failed to create task');
         }
@@ -290,9 +580,15 @@ async function createTask(taskData) {
 // Функция обновления задачи
 async function updateTask(taskId, taskData) {
     try {
+        // If-Match не дает перезаписать изменения, сделанные в другой вкладке
+        const headers = getAuthHeaders();
+        if (currentEditingETag) {
+            headers['If-Match'] = currentEditingETag;
+        }
+
         const response = await fetch(`${API_BASE}/tasks/${taskId}`, {
             method: 'PUT',
-            headers: getAuthHeaders(),
+            headers: headers,
             body: JSON.stringify(taskData)
         });
 
@@ -300,6 +596,10 @@ async function updateTask(taskId, taskData) {
             showNotification('Задача успешно обновлена', 'success');
             closeTaskModal();
             await loadTasks();
+        } else if (response.status === 412) {
+            showNotification('Задача была изменена в другом окне, откройте её заново', 'error');
+        } else if (response.status === 422) {
+            showValidationErrors(await response.json());
         } else {
             throw new Error('Failed to update task');
         }
@@ -310,13 +610,21 @@ async function updateTask(taskId, taskData) {
 }
 
 // Переключение статуса задачи
-async function toggleTaskStatus(taskId) {
+async function toggleTaskStatus(taskId, force = false) {
     try {
-        const response = await fetch(`${API_BASE}/tasks/${taskId}/toggle`, {
+        const response = await fetch(`${API_BASE}/tasks/${taskId}/toggle${force ? '?force=true' : ''}`, {
             method: 'PUT',
             headers: getAuthHeaders()
         });
 
+        // Задача ждет незавершенные блокирующие задачи
+        if (response.status === 409 && !force) {
+            if (confirm('Задача заблокирована незавершенными задачами. Все равно завершить?')) {
+                await toggleTaskStatus(taskId, true);
+            }
+            return;
+        }
+
         if (response.ok) {
             await loadTasks();
         } else {
@@ -366,6 +674,7 @@ async function editTask(taskId) {
 
         const task = await response.json();
         currentEditingTaskId = taskId;
+        currentEditingETag = response.headers.get('ETag');
 
         // Заполняем форму данными задачи
         document.getElementById('taskTitle').value = task.title;
@@ -373,13 +682,23 @@ async function editTask(taskId) {
         document.getElementById('taskPriority').value = task.priority;
 
         if (task.due_date) {
-            const date = new Date(task.due_date);
-            const formattedDate = date.toISOString().split('T')[0];
-            document.getElementById('taskDueDate').value = formattedDate;
+            document.getElementById('taskDueDate').value = toDateTimeLocalValue(task.due_date);
         } else {
             document.getElementById('taskDueDate').value = '';
         }
 
+        // Проект владельца общей задачи может отсутствовать в списке
+        const projectSelect = document.getElementById('taskProject');
+        if (task.project_id && !allProjects.some(project => project.id === task.project_id)) {
+            projectSelect.insertAdjacentHTML('beforeend', `<option value="${task.project_id}">Проект владельца</option>`);
+        }
+        projectSelect.value = task.project_id || '';
+
+        document.getElementById('taskTags').value = (task.tags || []).join(', ');
+
+        currentEditingRecurrence = task.recurrence || null;
+        document.getElementById('taskRecurrence').value = task.recurrence ? task.recurrence.freq : '';
+
         // Меняем заголовок и текст кнопки
         document.querySelector('#taskModal .modal-header h3').textContent = 'Редактировать задачу';
         document.querySelector('#taskModal button[type="submit"]').textContent = 'Сохранить изменения';
@@ -402,7 +721,13 @@ function setupEventListeners() {
             title: document.getElementById('taskTitle').value,
             description: document.getElementById('taskDescription').value,
             priority: document.getElementById('taskPriority').value,
-            due_date: document.getElementById('taskDueDate').value || null
+            due_date: document.getElementById('taskDueDate').value || null,
+            recurrence: getFormRecurrence(),
+            project_id: document.getElementById('taskProject').value || null,
+            tags: document.getElementById('taskTags').value
+                .split(',')
+                .map(tag => tag.trim())
+                .filter(tag => tag)
         };
 
         if (currentEditingTaskId) {
@@ -421,6 +746,12 @@ function setupEventListeners() {
         });
     });
 
+    // Фильтр по проекту
+    document.getElementById('projectFilter').addEventListener('change', async () => {
+        currentPage = 1;
+        await loadTasks();
+    });
+
     // Поиск задач
     document.getElementById('taskSearch').addEventListener('input', (e) => {
         searchTasks(e.target.value);
@@ -428,6 +759,7 @@ function setupEventListeners() {
 
     document.getElementById('changePasswordForm').addEventListener('submit', handlePasswordChange);
     document.getElementById('changeEmailForm').addEventListener('submit', handleEmailChange);
+    document.getElementById('changeTimezoneForm').addEventListener('submit', handleTimezoneChange);
 }
 
 // Фильтрация задач
@@ -478,6 +810,11 @@ function showSection(sectionName) {
     });
     document.getElementById(`${sectionName}-section`).classList.add('active');
 
+    if (sectionName === 'profile') {
+        loadSessions();
+        loadTwoFactor();
+    }
+
     document.querySelectorAll('.nav-btn').forEach(btn => {
         btn.classList.remove('active');
     });
@@ -497,10 +834,27 @@ function closeTaskModal() {
 function resetTaskModal() {
     document.getElementById('taskForm').reset();
     currentEditingTaskId = null;
+    currentEditingRecurrence = null;
+    currentEditingETag = null;
+    currentCreateKey = null;
     document.querySelector('#taskModal .modal-header h3').textContent = 'Новая задача';
     document.querySelector('#taskModal button[type="submit"]').textContent = 'Создать задачу';
 }
 
+// Правило повторения из формы: сохраняем детали правила, если частота не менялась
+function getFormRecurrence() {
+    const freq = document.getElementById('taskRecurrence').value;
+    if (!freq) {
+        return null;
+    }
+
+    if (currentEditingRecurrence && currentEditingRecurrence.freq === freq) {
+        return currentEditingRecurrence;
+    }
+
+    return { freq };
+}
+
 function resetAndOpenTaskModal() {
     resetTaskModal();
     openTaskModal();
@@ -581,15 +935,214 @@ async function handleEmailChange(e) {
     }
 }
 
+// Смена часового пояса
+async function handleTimezoneChange(e) {
+    e.preventDefault();
+
+    const timezone = document.getElementById('newTimezone').value.trim();
+
+    try {
+        const response = await fetch(`${API_BASE}/user/timezone`, {
+            method: 'PUT',
+            headers: getAuthHeaders(),
+            body: JSON.stringify({ timezone })
+        });
+
+        if (response.ok) {
+            showNotification('Часовой пояс успешно изменен', 'success');
+            await checkAuth();
+            await loadUserData();
+            await loadTasks();
+        } else {
+            const error = await response.text();
+            throw new Error(error);
+        }
+    } catch (error) {
+        console.error('Failed to change timezone:', error);
+        showNotification('Ошибка смены часового пояса', 'error');
+    }
+}
+
+// Загрузка активных сессий
+async function loadSessions() {
+    try {
+        const response = await fetch(`${API_BASE}/user/sessions`, {
+            headers: getAuthHeaders()
+        });
+
+        if (!response.ok) {
+            throw new Error('Failed to load sessions');
+        }
+
+        const sessions = await response.json();
+        document.getElementById('sessionsList').innerHTML = sessions.map(session => `
+            <div class="session-item">
+                <div>
+                    <div>${escapeHtml(session.user_agent || 'Неизвестное устройство')}${session.current ? ' (текущая)' : ''}</div>
+                    <div class="task-meta">${escapeHtml(session.ip)} · активность ${new Date(session.last_seen_at).toLocaleString('ru-RU')}</div>
+                </div>
+                ${session.current ? '' : `<button class="task-action-btn" onclick="revokeSession('${session.id}')" title="Завершить">✕</button>`}
+            </div>
+        `).join('');
+    } catch (error) {
+        console.error('Failed to load sessions:', error);
+        showNotification('Ошибка загрузки сессий', 'error');
+    }
+}
+
+// Завершение сессии на другом устройстве
+async function revokeSession(sessionId) {
+    try {
+        const response = await fetch(`${API_BASE}/user/sessions/${sessionId}`, {
+            method: 'DELETE',
+            headers: getAuthHeaders()
+        });
+
+        if (!response.ok) {
+            throw new Error('Failed to revoke session');
+        }
+
+        showNotification('Сессия завершена', 'success');
+        await loadSessions();
+    } catch (error) {
+        console.error('Failed to revoke session:', error);
+        showNotification('Ошибка завершения сессии', 'error');
+    }
+}
+
+// Состояние двухфакторной аутентификации
+async function loadTwoFactor() {
+    try {
+        const response = await fetch(`${API_BASE}/user/2fa`, {
+            headers: getAuthHeaders()
+        });
+
+        if (!response.ok) {
+            throw new Error('Failed to load 2FA status');
+        }
+
+        const status = await response.json();
+        document.getElementById('twoFactorSetup').style.display = 'none';
+
+        if (status.enabled) {
+            document.getElementById('twoFactorStatus').textContent =
+                `Включена. Осталось кодов восстановления: ${status.recovery_codes_left}`;
+            document.getElementById('twoFactorCodeGroup').style.display = 'block';
+            document.getElementById('twoFactorActions').innerHTML = `
+                <button class="btn-primary" onclick="regenerateRecoveryCodes()">Новые коды восстановления</button>
+                <button class="btn-primary" onclick="disableTwoFactor()">Отключить</button>
+            `;
+        } else {
+            document.getElementById('twoFactorStatus').textContent = 'Отключена';
+            document.getElementById('twoFactorCodeGroup').style.display = 'none';
+            document.getElementById('twoFactorActions').innerHTML =
+                '<button class="btn-primary" onclick="enrollTwoFactor()">Подключить</button>';
+        }
+    } catch (error) {
+        console.error('Failed to load 2FA status:', error);
+        showNotification('Ошибка загрузки настроек 2FA', 'error');
+    }
+}
+
+// Подключение 2FA: новый секрет для приложения-аутентификатора
+async function enrollTwoFactor() {
+    try {
+        const response = await fetch(`${API_BASE}/user/2fa/enroll`, {
+            method: 'POST',
+            headers: getAuthHeaders()
+        });
+
+        const result = await response.json();
+        if (!response.ok) {
+            throw new Error(result.error || 'Failed to enroll 2FA');
+        }
+
+        document.getElementById('twoFactorSecret').textContent = result.secret;
+        document.getElementById('twoFactorUri').href = result.otpauth_uri;
+        document.getElementById('twoFactorSetup').style.display = 'block';
+        document.getElementById('twoFactorCodeGroup').style.display = 'block';
+        document.getElementById('recoveryCodes').style.display = 'none';
+        document.getElementById('twoFactorActions').innerHTML =
+            '<button class="btn-primary" onclick="submitTwoFactorCode(\'confirm\')">Подтвердить</button>';
+    } catch (error) {
+        console.error('Failed to enroll 2FA:', error);
+        showNotification(error.message, 'error');
+    }
+}
+
+// Код 2FA: подтверждение подключения, новые коды восстановления или отключение
+async function submitTwoFactorCode(action) {
+    const code = document.getElementById('twoFactorCode').value.trim();
+    const isDisable = action === 'disable';
+
+    try {
+        const response = await fetch(`${API_BASE}/user/2fa${isDisable ? '' : '/' + action}`, {
+            method: isDisable ? 'DELETE' : 'POST',
+            headers: getAuthHeaders(),
+            body: JSON.stringify({ code })
+        });
+
+        const result = await response.json();
+        if (response.status === 429) {
+            throw new Error(`${result.error} (через ${result.retry_after} с)`);
+        }
+        if (!response.ok) {
+            throw new Error(result.error || 'Failed to update 2FA');
+        }
+
+        document.getElementById('twoFactorCode').value = '';
+        await loadTwoFactor();
+
+        // Коды восстановления показываются один раз
+        const recoveryCodes = document.getElementById('recoveryCodes');
+        if (result.recovery_codes) {
+            recoveryCodes.textContent = 'Сохраните коды восстановления:\n' + result.recovery_codes.join('\n');
+            recoveryCodes.style.display = 'block';
+        } else {
+            recoveryCodes.style.display = 'none';
+        }
+
+        showNotification(isDisable ? 'Двухфакторная аутентификация отключена' : 'Настройки 2FA сохранены', 'success');
+    } catch (error) {
+        console.error('Failed to update 2FA:', error);
+        showNotification(error.message, 'error');
+    }
+}
+
+function regenerateRecoveryCodes() {
+    submitTwoFactorCode('recovery-codes');
+}
+
+function disableTwoFactor() {
+    submitTwoFactorCode('disable');
+}
+
 // Выход из системы
-function logout() {
-    localStorage.removeItem('authToken');
-    localStorage.removeItem('userId');
-    localStorage.removeItem('userLogin');
+async function logout() {
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (refreshToken) {
+        try {
+            await fetch(`${API_BASE}/logout`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ refresh_token: refreshToken })
+            });
+        } catch (error) {
+            console.error('Logout failed:', error);
+        }
+    }
+
+    clearAuthStorage();
     window.location.href = '/';
 }
 
 // Вспомогательные функции
+// Показывает ошибки полей из ответа 422
+function showValidationErrors(data) {
+    const messages = (data.errors || []).map(error => error.message);
+    showNotification(messages.join('; ') || 'Проверьте данные задачи', 'error');
+}
+
 function getAuthHeaders() {
     const token = localStorage.getItem('authToken');
     return {
@@ -607,6 +1160,37 @@ function getPriorityText(priority) {
     return priorities[priority] || priority;
 }
 
+// Часовой пояс пользователя из профиля
+function getUserTimezone() {
+    return (currentUser && currentUser.timezone) || 'UTC';
+}
+
+// Срок задачи в часовом поясе пользователя
+function formatDueDate(dueDate) {
+    return new Date(dueDate).toLocaleString('ru-RU', {
+        timeZone: getUserTimezone(),
+        day: 'numeric',
+        month: 'short',
+        year: 'numeric',
+        hour: '2-digit',
+        minute: '2-digit'
+    });
+}
+
+// Значение для поля datetime-local в часовом поясе пользователя
+function toDateTimeLocalValue(dueDate) {
+    const parts = new Intl.DateTimeFormat('sv-SE', {
+        timeZone: getUserTimezone(),
+        year: 'numeric',
+        month: '2-digit',
+        day: '2-digit',
+        hour: '2-digit',
+        minute: '2-digit',
+        hourCycle: 'h23'
+    }).format(new Date(dueDate));
+    return parts.replace(' ', 'T');
+}
+
 function escapeHtml(text) {
     const div = document.createElement('div');
     div.textContent = text;
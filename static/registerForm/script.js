@@ -5,6 +5,7 @@ const registerForm = document.getElementById('registerForm');
 const loginForm = document.getElementById('loginForm');
 const registerResult = document.getElementById('registerResult');
 const loginResult = document.getElementById('loginResult');
+const twoFactorForm = document.getElementById('twoFactorForm');
 const healthStatus = document.getElementById('healthStatus');
 const statusIndicator = document.getElementById('statusIndicator');
 const statusText = document.getElementById('statusText');
@@ -90,17 +91,49 @@ loginForm.addEventListener('submit', async (e) => {
 
         const result = await response.json();
 
-        if (response.ok) {
-            // Сохраняем токен в localStorage
-            localStorage.setItem('authToken', result.token);
-            //localStorage.setItem('userId', result.id);
+        if (response.ok && result.two_factor_required) {
+            // Пароль верный, нужен код второго фактора
+            twoFactorForm.dataset.challengeToken = result.challenge_token;
+            loginForm.style.display = 'none';
+            twoFactorForm.style.display = 'block';
+            loginResult.className = 'result';
+            loginResult.textContent = '';
+            document.getElementById('twoFactorCode').focus();
+        } else if (response.ok) {
+            completeLogin(result);
+        } else if (response.status === 429) {
+            throw new Error(`${result.error} (через ${result.retry_after} с)`);
+        } else {
+            throw new Error(result.error || 'Ошибка входа');
+        }
+    } catch (error) {
+        loginResult.className = 'result error';
+        loginResult.textContent = `Ошибка: ${error.message}`;
+    }
+});
+
+// Второй шаг входа: код TOTP или код восстановления
+twoFactorForm.addEventListener('submit', async (e) => {
+    e.preventDefault();
 
-            loginResult.className = 'result success';
-            loginResult.textContent = `Успешный вход! Добро пожаловать!`;
-            loginForm.reset();
+    try {
+        const response = await fetch(`${API_BASE}/login/2fa`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({
+                challenge_token: twoFactorForm.dataset.challengeToken,
+                code: new FormData(twoFactorForm).get('code')
+            })
+        });
 
-            // Немедленный редирект на dashboard
-            window.location.href = '/dashboard';
+        const result = await response.json();
+
+        if (response.ok) {
+            completeLogin(result);
+        } else if (response.status === 429) {
+            throw new Error(`${result.error} (через ${result.retry_after} с)`);
         } else {
             throw new Error(result.error || 'Ошибка входа');
         }
@@ -110,6 +143,23 @@ loginForm.addEventListener('submit', async (e) => {
     }
 });
 
+// Сохраняем токены и переходим на dashboard
+function completeLogin(result) {
+    // Сохраняем токен в localStorage
+    localStorage.setItem('authToken', result.token);
+    localStorage.setItem('refreshToken', result.refresh_token);
+    localStorage.setItem('tokenExpiresAt', String(Date.now() + result.expires_in * 1000));
+    //localStorage.setItem('userId', result.id);
+
+    loginResult.className = 'result success';
+    loginResult.textContent = `Успешный вход! Добро пожаловать!`;
+    loginForm.reset();
+    twoFactorForm.reset();
+
+    // Немедленный редирект на dashboard
+    window.location.href = '/dashboard';
+}
+
 // Функция для получения заголовков с токеном
 function getAuthHeaders() {
     const token = localStorage.getItem('authToken');